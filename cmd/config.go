@@ -2,11 +2,21 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"sort"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/FreifunkBremen/yanic/database"
+	allDatabase "github.com/FreifunkBremen/yanic/database/all"
+	"github.com/FreifunkBremen/yanic/output"
+	allOutput "github.com/FreifunkBremen/yanic/output/all"
 	"github.com/FreifunkBremen/yanic/respond"
 	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/FreifunkBremen/yanic/webserver"
+	"github.com/spf13/cobra"
 )
 
 var (
@@ -22,5 +32,148 @@ func loadConfig() *runtime.Config {
 		fmt.Fprintln(os.Stderr, "unable to load config file:", err)
 		os.Exit(2)
 	}
+	if errs := config.Validate(); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, "invalid config file:", err)
+		}
+		os.Exit(2)
+	}
 	return config
 }
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Checks or prints the configuration",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:     "check",
+	Short:   "Checks the configuration file and reports every problem",
+	Example: "yanic config check --config /etc/yanic.toml",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		problems, err := checkConfig(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", configPath, err)
+			os.Exit(1)
+		}
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		fmt.Println(configPath + ": ok")
+	},
+}
+
+var configDefaultsCmd = &cobra.Command{
+	Use:     "defaults",
+	Short:   "Prints a configuration with all options and their defaults",
+	Example: "yanic config defaults > /etc/yanic.toml",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := os.Stdout
+		runtime.WriteConfigSections(w)
+
+		fmt.Fprintln(w, "# Filters of each output")
+		fmt.Fprintln(w, "#[nodes.output.<type>.filter]")
+		allOutput.FilterOptions.Write(w, true)
+		fmt.Fprintln(w, "#[nodes.output.<type>.filter.in_area]")
+		allOutput.AreaOptions.Write(w, true)
+		fmt.Fprintln(w, "#[nodes.output.<type>.filter.location_privacy]")
+		allOutput.LocationPrivacyOptions.Write(w, true)
+		fmt.Fprintln(w, "#[[nodes.output.<type>.transform]]")
+		allOutput.TransformOptions.Write(w, true)
+		fmt.Fprintln(w, "#[nodes.output.<type>.upload]")
+		output.UploadOptions.Write(w, true)
+		fmt.Fprintln(w)
+
+		writeAdapters(w, "nodes.output", output.Options)
+		writeAdapters(w, "database.connection", database.Options)
+	},
+}
+
+var configReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reloads the configuration of a running yanic server",
+	Long: `Reloads the configuration of a running yanic server using its admin API, like sending SIGHUP.
+The address and the token are read from the webserver section of the configuration.`,
+	Example: "yanic config reload --config /etc/yanic.toml",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		adminRequest(http.MethodPost, webserver.ReloadPath, nil)
+	},
+}
+
+// writeAdapters prints the options of the adapters as disabled examples
+func writeAdapters(w *os.File, prefix string, adapters map[string]runtime.Options) {
+	names := make([]string, 0, len(adapters))
+	for name := range adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "#[[%s.%s]]\n", prefix, name)
+		adapters[name].Write(w, true)
+		fmt.Fprintln(w)
+	}
+}
+
+// checkConfig returns the problems of the configuration file prefixed by their location,
+// the error is set if the file could not be parsed
+func checkConfig(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := runtime.DefaultConfig()
+	md, err := toml.Decode(string(content), config)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, key := range md.Undecoded() {
+		// the options of the adapters are checked by them
+		if name := key.String(); !strings.HasPrefix(name, "nodes.output.") && !strings.HasPrefix(name, "database.connection.") {
+			errs = append(errs, &runtime.OptionError{Key: name, Msg: "unknown option"})
+		}
+	}
+	errs = append(errs, config.Validate()...)
+	errs = append(errs, allOutput.Check(config.Nodes.Output)...)
+	errs = append(errs, allDatabase.Check(config.Database.Connection)...)
+
+	lines := runtime.ConfigLines(content)
+	type problem struct {
+		line int
+		text string
+	}
+	var problems []problem
+	for _, err := range errs {
+		line := 0
+		if optionErr, ok := err.(*runtime.OptionError); ok {
+			line = runtime.ConfigLine(lines, optionErr.Key)
+		}
+		problems = append(problems, problem{line, fmt.Sprintf("%s:%d: %s", path, line, err)})
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].line < problems[j].line
+	})
+
+	result := make([]string, len(problems))
+	for i, p := range problems {
+		result[i] = p.text
+	}
+	return result, nil
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "config.toml", "Path to configuration file")
+	configReloadCmd.Flags().StringVar(&adminServer, "server", "", "URL of the yanic webserver (default: from the configuration)")
+	configReloadCmd.Flags().StringVar(&adminToken, "token", "", "Token of the admin API (default: from the configuration)")
+	configCmd.AddCommand(configCheckCmd, configDefaultsCmd, configReloadCmd)
+}
@@ -38,6 +38,7 @@ var importCmd = &cobra.Command{
 				},
 				ds.Time,
 				site,
+				runtime.GLOBAL_DOMAIN,
 			)
 		}
 	},
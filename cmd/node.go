@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/webserver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adminServer string
+	adminToken  string
+
+	overrideClear     bool
+	overrideHostname  string
+	overrideOwner     string
+	overrideNote      string
+	overrideLatitude  float64
+	overrideLongitude float64
+)
+
+// nodeCmd represents the node command
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Manages the nodes of a running yanic server",
+	Long: `Manages the nodes of a running yanic server using its admin API.
+The address and the token are read from the webserver section of the configuration.`,
+}
+
+var nodeShowCmd = &cobra.Command{
+	Use:     "show <nodeid>",
+	Short:   "Shows the stored data of a node",
+	Example: "yanic node show 001122334455 --config /etc/yanic.toml",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		adminRequest(http.MethodGet, webserver.AdminPath+args[0], nil)
+	},
+}
+
+var nodeDeleteCmd = &cobra.Command{
+	Use:     "delete <nodeid>",
+	Short:   "Removes a node",
+	Example: "yanic node delete 001122334455 --config /etc/yanic.toml",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		adminRequest(http.MethodDelete, webserver.AdminPath+args[0], nil)
+	},
+}
+
+var nodeMergeCmd = &cobra.Command{
+	Use:     "merge <old nodeid> <new nodeid>",
+	Short:   "Merges a node into another one, e.g. after swapping the hardware",
+	Example: "yanic node merge 001122334455 665544332211 --config /etc/yanic.toml",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		adminRequest(http.MethodPost, webserver.AdminPath+args[0]+"/merge?into="+url.QueryEscape(args[1]), nil)
+	},
+}
+
+var nodeOverrideCmd = &cobra.Command{
+	Use:   "override <nodeid>",
+	Short: "Overrides the hostname, owner or location of a node or adds a note",
+	Example: `yanic node override 001122334455 --hostname "Town Hall" --latitude 53.07 --longitude 8.81
+yanic node override 001122334455 --clear`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if overrideClear {
+			adminRequest(http.MethodDelete, webserver.AdminPath+args[0]+"/overrides", nil)
+			return
+		}
+
+		// only send the given values
+		overrides := make(map[string]interface{})
+		flags := cmd.Flags()
+		if flags.Changed("hostname") {
+			overrides["hostname"] = overrideHostname
+		}
+		if flags.Changed("owner") {
+			overrides["owner"] = overrideOwner
+		}
+		if flags.Changed("note") {
+			overrides["note"] = overrideNote
+		}
+		if flags.Changed("latitude") != flags.Changed("longitude") {
+			fmt.Fprintln(os.Stderr, "the location requires --latitude and --longitude")
+			os.Exit(1)
+		}
+		if flags.Changed("latitude") {
+			overrides["location"] = &data.Location{Latitude: overrideLatitude, Longitude: overrideLongitude}
+		}
+		if len(overrides) == 0 {
+			fmt.Fprintln(os.Stderr, "nothing to override")
+			os.Exit(1)
+		}
+		adminRequest(http.MethodPatch, webserver.AdminPath+args[0]+"/overrides", overrides)
+	},
+}
+
+var nodeMaintenanceCmd = &cobra.Command{
+	Use:     "maintenance <nodeid> on|off",
+	Short:   "Sets or removes the maintenance flag of a node",
+	Example: "yanic node maintenance 001122334455 on --config /etc/yanic.toml",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		switch args[1] {
+		case "on":
+			adminRequest(http.MethodPut, webserver.AdminPath+args[0]+"/maintenance", nil)
+		case "off":
+			adminRequest(http.MethodDelete, webserver.AdminPath+args[0]+"/maintenance", nil)
+		default:
+			fmt.Fprintln(os.Stderr, "maintenance has to be on or off")
+			os.Exit(1)
+		}
+	},
+}
+
+var nodeQueryCmd = &cobra.Command{
+	Use:     "query <nodeid>",
+	Short:   "Sends an immediate unicast request to a node",
+	Example: "yanic node query 001122334455 --config /etc/yanic.toml",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		adminRequest(http.MethodPost, webserver.AdminPath+args[0]+"/query", nil)
+	},
+}
+
+// adminRequest sends a request to the admin API at the given path and prints the response
+func adminRequest(method, path string, body interface{}) {
+	server, token := adminServer, adminToken
+	if server == "" || token == "" {
+		config := loadConfig()
+		if server == "" {
+			server = "http://" + config.Webserver.Bind
+		}
+		if token == "" {
+			token = config.Webserver.AdminToken
+		}
+	}
+
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(server, "/")+path, reader)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer res.Body.Close()
+	content, _ := ioutil.ReadAll(res.Body)
+
+	switch {
+	case res.StatusCode >= 300:
+		fmt.Fprintf(os.Stderr, "%s: %s", res.Status, content)
+		os.Exit(1)
+	case len(content) > 0:
+		var out bytes.Buffer
+		if err := json.Indent(&out, content, "", "  "); err == nil {
+			content = out.Bytes()
+		}
+		fmt.Println(strings.TrimSpace(string(content)))
+	default:
+		fmt.Println(res.Status)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(nodeCmd)
+	nodeCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "config.toml", "Path to configuration file")
+	nodeCmd.PersistentFlags().StringVar(&adminServer, "server", "", "URL of the yanic webserver (default: from the configuration)")
+	nodeCmd.PersistentFlags().StringVar(&adminToken, "token", "", "Token of the admin API (default: from the configuration)")
+
+	nodeOverrideCmd.Flags().BoolVar(&overrideClear, "clear", false, "Removes all overrides")
+	nodeOverrideCmd.Flags().StringVar(&overrideHostname, "hostname", "", "Hostname")
+	nodeOverrideCmd.Flags().StringVar(&overrideOwner, "owner", "", "Contact of the owner")
+	nodeOverrideCmd.Flags().StringVar(&overrideNote, "note", "", "Note of an administrator")
+	nodeOverrideCmd.Flags().Float64Var(&overrideLatitude, "latitude", 0, "Latitude of the pinned location")
+	nodeOverrideCmd.Flags().Float64Var(&overrideLongitude, "longitude", 0, "Longitude of the pinned location")
+
+	nodeCmd.AddCommand(nodeShowCmd, nodeDeleteCmd, nodeMergeCmd, nodeOverrideCmd, nodeMaintenanceCmd, nodeQueryCmd)
+}
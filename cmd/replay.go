@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/FreifunkBremen/yanic/database"
+	allDatabase "github.com/FreifunkBremen/yanic/database/all"
+	"github.com/FreifunkBremen/yanic/output"
+	allOutput "github.com/FreifunkBremen/yanic/output/all"
+	"github.com/FreifunkBremen/yanic/respond"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/spf13/cobra"
+)
+
+var realtime bool
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:     "replay <capture>",
+	Short:   "Replays captured respondd responses to the configured outputs and databases",
+	Example: "yanic replay --config /etc/yanic.toml /var/lib/yanic/capture.json",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// the connections are closed before exiting, to write their buffered data
+		if err := replay(args[0]); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func replay(path string) error {
+	config := loadConfig()
+
+	// do not touch the state of a running instance
+	config.Nodes.StatePath = ""
+
+	connections, err := allDatabase.Connect(config.Database.Connection)
+	if err != nil {
+		panic(err)
+	}
+	database.Start(connections, config)
+	defer database.Close(connections)
+
+	nodes = runtime.NewNodes(config)
+
+	outputs, err := allOutput.Register(config.Nodes.Output)
+	if err != nil {
+		panic(err)
+	}
+	if realtime {
+		output.Start(outputs, nodes, config)
+		defer output.Close()
+	}
+
+	log.Println("replaying responses from", path)
+
+	collector = respond.NewCollector(connections, nodes, config.Respondd.Sites, []string{}, 0)
+	collector.SetDomains(config.Respondd.Domains)
+	if err := collector.Replay(path, realtime); err != nil {
+		return err
+	}
+
+	log.Println("replayed", len(nodes.List), "nodes")
+	return outputs.Save(nodes)
+}
+
+func init() {
+	RootCmd.AddCommand(replayCmd)
+	replayCmd.Flags().StringVarP(&configPath, "config", "c", "config.toml", "Path to configuration file")
+	replayCmd.Flags().BoolVar(&realtime, "realtime", false, "Replay the responses at original speed instead of as fast as possible")
+}
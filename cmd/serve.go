@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -21,55 +28,290 @@ import (
 var serveCmd = &cobra.Command{
 	Use:     "serve",
 	Short:   "Runs the yanic server",
+	Long:    "Runs the yanic server, SIGHUP reloads the configuration file.",
 	Example: "yanic serve --config /etc/yanic.toml",
 	Run: func(cmd *cobra.Command, args []string) {
 		config := loadConfig()
 
-		connections, err := allDatabase.Connect(config.Database.Connection)
-		if err != nil {
-			panic(err)
+		s := &server{config: config}
+		s.start()
+		defer s.close()
+
+		// Wait for INT/TERM, reload on HUP
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		for sig := range sigs {
+			log.Println("received", sig)
+			if sig != syscall.SIGHUP {
+				break
+			}
+			if err := s.reload(); err != nil {
+				log.Println("reload failed:", err)
+			}
 		}
-		database.Start(connections, config)
-		defer database.Close(connections)
+	},
+}
+
+// server holds the running parts of yanic, which are replaced on reload
+type server struct {
+	config      *runtime.Config // the applied configuration
+	connections *allDatabase.Connection
+	outputs     *allOutput.Output
+	srv         *webserver.Server
+	sync.Mutex  // held while reloading
+
+	collector      *respond.Collector // read by the handlers of the webserver
+	collectorMutex sync.Mutex
+}
+
+func (s *server) start() {
+	config := s.config
+
+	connections, err := allDatabase.Connect(config.Database.Connection)
+	if err != nil {
+		panic(err)
+	}
+	s.connections = connections.(*allDatabase.Connection)
+	database.Start(s.connections, config)
 
-		nodes = runtime.NewNodes(config)
-		nodes.Start()
+	nodes = runtime.NewNodes(config)
+	nodes.Start()
 
-		outputs, err := allOutput.Register(config.Nodes.Output)
+	outputs, err := allOutput.Register(config.Nodes.Output)
+	if err != nil {
+		panic(err)
+	}
+	s.outputs = outputs.(*allOutput.Output)
+	output.Start(s.outputs, nodes, config)
+
+	if config.Webserver.Enable {
+		s.srv = s.newWebserver(config)
+		go webserver.Start(s.srv)
+	}
+
+	if config.Respondd.Enable {
+		// Delaying startup to start at a multiple of `duration` since the zero time.
+		if duration := config.Respondd.Synchronize.Duration; duration > 0 {
+			now := time.Now()
+			delay := duration - now.Sub(now.Truncate(duration))
+			log.Printf("delaying %0.1f seconds", delay.Seconds())
+			time.Sleep(delay)
+		}
+
+		collector, err := s.newCollector(config)
 		if err != nil {
 			panic(err)
 		}
-		output.Start(outputs, nodes, config)
-		defer output.Close()
-
-		if config.Webserver.Enable {
-			log.Println("starting webserver on", config.Webserver.Bind)
-			srv := webserver.New(config.Webserver.Bind, config.Webserver.Webroot)
-			go webserver.Start(srv)
-			defer srv.Close()
+		s.setCollector(collector)
+	}
+}
+
+func (s *server) close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if collector := s.getCollector(); collector != nil {
+		collector.Close()
+	}
+	if s.srv != nil {
+		s.srv.Close()
+	}
+	output.Close()
+	database.Close(s.connections)
+}
+
+// reload reads the configuration file again and rebuilds the changed parts, the nodes are kept.
+// Parts, which could not be changed, keep their previous configuration and are retried on the next reload.
+func (s *server) reload() error {
+	s.Lock()
+	defer s.Unlock()
+
+	config, err := runtime.ReadConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+	// sets the defaults of the adapters as well, to compare them to the running ones
+	errs := config.Validate()
+	errs = append(errs, allOutput.Check(config.Nodes.Output)...)
+	errs = append(errs, allDatabase.Check(config.Database.Connection)...)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	old := s.config
+	applied := *config
+	var failures []string
+	failed := func(part string, err error) {
+		failures = append(failures, fmt.Sprintf("%s: %s", part, err))
+	}
+
+	// databases
+	if !reflect.DeepEqual(old.Database.Connection, config.Database.Connection) {
+		log.Println("reload: database connections changed")
+		if err := s.connections.Reload(config.Database.Connection); err != nil {
+			failed("database", err)
+			applied.Database.Connection = old.Database.Connection
 		}
+	}
+	if old.Database.DeleteInterval != config.Database.DeleteInterval || old.Database.DeleteAfter != config.Database.DeleteAfter {
+		database.Close(nil)
+		database.Start(s.connections, config)
+	}
 
-		if config.Respondd.Enable {
-			// Delaying startup to start at a multiple of `duration` since the zero time.
-			if duration := config.Respondd.Synchronize.Duration; duration > 0 {
-				now := time.Now()
-				delay := duration - now.Sub(now.Truncate(duration))
-				log.Printf("delaying %0.1f seconds", delay.Seconds())
-				time.Sleep(delay)
+	// nodes and outputs
+	nodes.SetConfig(config)
+	if !reflect.DeepEqual(old.Nodes.Output, config.Nodes.Output) {
+		log.Println("reload: outputs changed")
+		if err := s.outputs.Reload(config.Nodes.Output); err != nil {
+			failed("output", err)
+			applied.Nodes.Output = old.Nodes.Output
+		}
+	}
+	if old.Nodes.SaveInterval != config.Nodes.SaveInterval {
+		output.Close()
+		output.Start(s.outputs, nodes, config)
+	}
+
+	// collector
+	if err := s.reloadCollector(old, config); err != nil {
+		failed("respondd", err)
+		applied.Respondd = old.Respondd
+	}
+
+	// webserver
+	if !reflect.DeepEqual(old.Webserver, config.Webserver) {
+		log.Println("reload: webserver changed")
+		s.restartWebserver(config)
+	}
+
+	s.config = &applied
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, ", "))
+	}
+	log.Println("reload: done")
+	return nil
+}
+
+// reloadCollector changes the running collector or creates a new one, if it can not be changed
+func (s *server) reloadCollector(old, config *runtime.Config) error {
+	collector := s.getCollector()
+	changed, wanted := old.Respondd, config.Respondd
+	if collector != nil && wanted.Enable && changed.Port == wanted.Port &&
+		changed.CollectInterval == wanted.CollectInterval && changed.CapturePath == wanted.CapturePath {
+		if !reflect.DeepEqual(changed.Interfaces, wanted.Interfaces) {
+			log.Println("reload: collector interfaces changed")
+			if err := collector.SetInterfaces(wanted.Interfaces); err != nil {
+				return err
 			}
+		}
+		collector.SetSites(wanted.Sites)
+		collector.SetDomains(wanted.Domains)
+		collector.SetUnicastConfig(wanted.Unicast)
+		return nil
+	}
+	if collector != nil {
+		log.Println("reload: stopping collector")
+		s.setCollector(nil)
+		collector.Close()
+	}
+	if !wanted.Enable {
+		return nil
+	}
+	log.Println("reload: starting collector")
+	collector, err := s.newCollector(config)
+	if err != nil {
+		return err
+	}
+	s.setCollector(collector)
+	return nil
+}
 
-			collector = respond.NewCollector(connections, nodes, config.Respondd.Sites, config.Respondd.Interfaces, config.Respondd.Port)
-			collector.Start(config.Respondd.CollectInterval.Duration)
-			defer collector.Close()
+// newCollector creates and starts a collector
+func (s *server) newCollector(config *runtime.Config) (*respond.Collector, error) {
+	collector := respond.NewCollector(s.connections, nodes, config.Respondd.Sites, nil, config.Respondd.Port)
+	if err := collector.SetInterfaces(config.Respondd.Interfaces); err != nil {
+		collector.Close()
+		return nil, err
+	}
+	collector.SetDomains(config.Respondd.Domains)
+	collector.SetUnicastConfig(config.Respondd.Unicast)
+	if path := config.Respondd.CapturePath; path != "" {
+		capture, err := respond.NewCapture(path)
+		if err != nil {
+			collector.Close()
+			return nil, err
 		}
+		log.Println("capturing responses to", path)
+		collector.SetCapture(capture)
+	}
+	collector.Start(config.Respondd.CollectInterval.Duration)
+	return collector, nil
+}
 
-		// Wait for INT/TERM
-		sigs := make(chan os.Signal, 1)
-		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-		sig := <-sigs
-		log.Println("received", sig)
+func (s *server) getCollector() *respond.Collector {
+	s.collectorMutex.Lock()
+	defer s.collectorMutex.Unlock()
+	return s.collector
+}
 
-	},
+func (s *server) setCollector(collector *respond.Collector) {
+	s.collectorMutex.Lock()
+	s.collector = collector
+	s.collectorMutex.Unlock()
+}
+
+// newWebserver creates a webserver with the API handlers
+func (s *server) newWebserver(config *runtime.Config) *webserver.Server {
+	log.Println("starting webserver on", config.Webserver.Bind)
+	srv := webserver.New(config.Webserver.Bind, config.Webserver.Webroot)
+
+	srv.HandleJSON("/api/conflicts", func() interface{} {
+		return nodes.Conflicts()
+	})
+	srv.HandleJSON("/api/collector", func() interface{} {
+		if collector := s.getCollector(); collector != nil {
+			return collector.Stats()
+		}
+		return nil
+	})
+	srv.HandleJSON("/api/outputs", func() interface{} {
+		return s.outputs.Stats()
+	})
+
+	if token := config.Webserver.AdminToken; token != "" {
+		srv.Handle(webserver.AdminPath, webserver.NewAdminHandler(token, nodes, func(nodeID string) error {
+			collector := s.getCollector()
+			if collector == nil {
+				return webserver.ErrCollectorStopped
+			}
+			return collector.Query(nodeID)
+		}))
+		srv.Handle(webserver.ReloadPath, webserver.NewReloadHandler(token, s.reload))
+	}
+	return srv
+}
+
+// restartWebserver replaces the webserver, the old one finishes the running requests
+// (e.g. the reload request) before the new one starts
+func (s *server) restartWebserver(config *runtime.Config) {
+	old := s.srv
+	s.srv = nil
+	if config.Webserver.Enable {
+		s.srv = s.newWebserver(config)
+	}
+
+	go func(srv *webserver.Server) {
+		if old != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			old.Shutdown(ctx)
+			cancel()
+		}
+		if srv == nil {
+			return
+		}
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.Println("webserver failed:", err)
+		}
+	}(s.srv)
 }
 
 func init() {
@@ -36,6 +36,35 @@ type Network struct {
 	MeshInterfaces []string                 `json:"mesh_interfaces"`
 }
 
+// InterfaceType returns the type of the mesh interface with the MAC address,
+// which is "wireless", "other", "tunnel" or "" if unknown
+func (network *Network) InterfaceType(mac string) string {
+	for _, mesh := range network.Mesh {
+		if mesh == nil {
+			continue
+		}
+		if containsString(mesh.Interfaces.Tunnel, mac) {
+			return "tunnel"
+		}
+		if containsString(mesh.Interfaces.Wireless, mac) {
+			return "wireless"
+		}
+		if containsString(mesh.Interfaces.Other, mac) {
+			return "other"
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
 // Owner struct
 type Owner struct {
 	Contact string `json:"contact"`
@@ -43,7 +72,8 @@ type Owner struct {
 
 // System struct
 type System struct {
-	SiteCode string `json:"site_code,omitempty"`
+	SiteCode   string `json:"site_code,omitempty"`
+	DomainCode string `json:"domain_code,omitempty"` // Gluon multidomain
 }
 
 // Location struct
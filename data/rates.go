@@ -0,0 +1,97 @@
+package data
+
+import "math"
+
+// Rates are computed from the counters of two consecutive statistics of a node
+type Rates struct {
+	Interval float64 `json:"interval"` // seconds between both statistics (uptime of the node)
+
+	Rx      *TrafficRate `json:"rx,omitempty"`
+	Tx      *TrafficRate `json:"tx,omitempty"`
+	Forward *TrafficRate `json:"forward,omitempty"`
+	MgmtRx  *TrafficRate `json:"mgmt_rx,omitempty"`
+	MgmtTx  *TrafficRate `json:"mgmt_tx,omitempty"`
+
+	ForwardRatio *float64 `json:"forward_ratio,omitempty"` // forwarded bytes per received byte
+	CPUUsage     *float64 `json:"cpu_usage,omitempty"`     // 1.0 equals all processors busy
+}
+
+// Traffic returns the available traffic rates by the name of the counter, e.g. mgmt_rx
+func (rates *Rates) Traffic() map[string]*TrafficRate {
+	result := make(map[string]*TrafficRate)
+	for name, rate := range map[string]*TrafficRate{
+		"rx":      rates.Rx,
+		"tx":      rates.Tx,
+		"forward": rates.Forward,
+		"mgmt_rx": rates.MgmtRx,
+		"mgmt_tx": rates.MgmtTx,
+	} {
+		if rate != nil {
+			result[name] = rate
+		}
+	}
+	return result
+}
+
+// TrafficRate per second
+type TrafficRate struct {
+	Bytes   float64 `json:"bytes"`
+	Packets float64 `json:"packets"`
+	Dropped float64 `json:"dropped,omitempty"`
+}
+
+// SetRates calculates the rates in regard to the previous statistics of the node with nproc processors.
+// There are no rates after a reboot (the uptime decreased) and none of a counter, which was reset or wrapped.
+func (current *Statistics) SetRates(previous *Statistics, nproc int) {
+	interval := current.Uptime - previous.Uptime
+	if interval == 0 {
+		// the same statistics again, e.g. answers to multicast and unicast requests
+		current.Rates = previous.Rates
+		return
+	}
+	current.Rates = nil
+	if interval < 0 {
+		return
+	}
+
+	rates := &Rates{
+		Interval: interval,
+		Rx:       current.Traffic.Rx.rate(previous.Traffic.Rx, interval),
+		Tx:       current.Traffic.Tx.rate(previous.Traffic.Tx, interval),
+		Forward:  current.Traffic.Forward.rate(previous.Traffic.Forward, interval),
+		MgmtRx:   current.Traffic.MgmtRx.rate(previous.Traffic.MgmtRx, interval),
+		MgmtTx:   current.Traffic.MgmtTx.rate(previous.Traffic.MgmtTx, interval),
+	}
+	if rates.Rx != nil && rates.Forward != nil && rates.Rx.Bytes > 0 {
+		ratio := rates.Forward.Bytes / rates.Rx.Bytes
+		rates.ForwardRatio = &ratio
+	}
+
+	// the idle time is the sum of all processors
+	if idle := current.Idletime - previous.Idletime; idle >= 0 && (current.Idletime > 0 || previous.Idletime > 0) {
+		if nproc < 1 {
+			nproc = 1
+		}
+		usage := math.Max(0, math.Min(1, 1-idle/(interval*float64(nproc))))
+		rates.CPUUsage = &usage
+	}
+	current.Rates = rates
+}
+
+// rate returns the rate per second or nil if a counter decreased
+func (current *Traffic) rate(previous *Traffic, interval float64) *TrafficRate {
+	if current == nil || previous == nil {
+		return nil
+	}
+	bytes := current.Bytes - previous.Bytes
+	packets := current.Packets - previous.Packets
+	dropped := current.Dropped - previous.Dropped
+	if bytes < 0 || packets < 0 || dropped < 0 {
+		return nil
+	}
+	return &TrafficRate{
+		Bytes:   bytes / interval,
+		Packets: packets / interval,
+		Dropped: dropped / interval,
+	}
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRates(t *testing.T) {
+	assert := assert.New(t)
+
+	previous := &Statistics{Uptime: 100, Idletime: 150}
+	previous.Traffic.Rx = &Traffic{Bytes: 1000, Packets: 10}
+	previous.Traffic.Tx = &Traffic{Bytes: 5000, Packets: 50, Dropped: 2}
+	previous.Traffic.Forward = &Traffic{Bytes: 500, Packets: 5}
+
+	current := &Statistics{Uptime: 110, Idletime: 165}
+	current.Traffic.Rx = &Traffic{Bytes: 3000, Packets: 30}
+	current.Traffic.Tx = &Traffic{Bytes: 4000, Packets: 60, Dropped: 2} // wrapped
+	current.Traffic.Forward = &Traffic{Bytes: 1000, Packets: 10}
+	current.Traffic.MgmtRx = &Traffic{Bytes: 10}
+
+	current.SetRates(previous, 2)
+	rates := current.Rates
+	assert.NotNil(rates)
+	assert.Equal(float64(10), rates.Interval)
+	assert.Equal(&TrafficRate{Bytes: 200, Packets: 2}, rates.Rx)
+	assert.Nil(rates.Tx, "counter wrapped")
+	assert.Equal(&TrafficRate{Bytes: 50, Packets: 0.5}, rates.Forward)
+	assert.Nil(rates.MgmtRx, "no previous counter")
+	assert.Len(rates.Traffic(), 2)
+	assert.Equal(0.25, *rates.ForwardRatio)
+	assert.Equal(0.25, *rates.CPUUsage)
+
+	// the same statistics again
+	again := *current
+	again.Rates = nil
+	again.SetRates(current, 2)
+	assert.True(rates == again.Rates)
+
+	// reboot
+	rebooted := &Statistics{Uptime: 5, Idletime: 4}
+	rebooted.Traffic.Rx = &Traffic{Bytes: 10, Packets: 1}
+	rebooted.SetRates(current, 2)
+	assert.Nil(rebooted.Rates)
+
+	// no idle time reported
+	previous = &Statistics{Uptime: 100}
+	current = &Statistics{Uptime: 160}
+	current.SetRates(previous, 0)
+	assert.NotNil(current.Rates)
+	assert.Nil(current.Rates.CPUUsage)
+	assert.Nil(current.Rates.ForwardRatio)
+}
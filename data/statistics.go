@@ -1,5 +1,7 @@
 package data
 
+import "time"
+
 /*
 	Nodes Lua based respondd do not have a integer type.
 	They always return float.
@@ -31,11 +33,17 @@ type Statistics struct {
 	} `json:"traffic,omitempty"`
 	Switch   map[string]*SwitchPort `json:"switch,omitempty"`
 	Wireless WirelessStatistics     `json:"wireless,omitempty"`
+	Rates    *Rates                 `json:"rates,omitempty"` // computed by yanic, see SetRates
 }
 
+// MeshVPNHandshakeTimeout is the period after the latest WireGuard handshake,
+// after which the peer is not established anymore (WireGuard rejects the session after 180s)
+const MeshVPNHandshakeTimeout = 3 * time.Minute
+
 // MeshVPNPeerLink struct
 type MeshVPNPeerLink struct {
-	Established float64 `json:"established"`
+	Established     float64 `json:"established"`                // uptime in seconds
+	LatestHandshake float64 `json:"latest_handshake,omitempty"` // unix time of the latest WireGuard handshake
 }
 
 // MeshVPNPeerGroup struct
@@ -44,9 +52,62 @@ type MeshVPNPeerGroup struct {
 	Groups map[string]*MeshVPNPeerGroup `json:"groups"`
 }
 
-// MeshVPN struct
+// MeshVPNTunneldigger is the state of a tunneldigger (L2TP) client
+type MeshVPNTunneldigger struct {
+	Broker      string  `json:"broker"`      // the connected broker
+	Established float64 `json:"established"` // uptime in seconds, 0 if not connected
+}
+
+// MeshVPN struct, fastd reports groups, WireGuard reports its peers directly
 type MeshVPN struct {
-	Groups map[string]*MeshVPNPeerGroup `json:"groups,omitempty"`
+	Provider     string                       `json:"provider,omitempty"` // e.g. wireguard
+	Groups       map[string]*MeshVPNPeerGroup `json:"groups,omitempty"`
+	Peers        map[string]*MeshVPNPeerLink  `json:"peers,omitempty"`
+	Tunneldigger *MeshVPNTunneldigger         `json:"tunneldigger,omitempty"`
+}
+
+// EstablishedPeers returns the uptime in seconds of the established peers by name.
+// The latest WireGuard handshakes are compared to now, the time the statistics were received.
+func (vpn *MeshVPN) EstablishedPeers(now time.Time) map[string]float64 {
+	peers := make(map[string]float64)
+	addPeers(peers, vpn.Peers, now)
+	for _, group := range vpn.Groups {
+		group.addPeers(peers, now)
+	}
+	if td := vpn.Tunneldigger; td != nil && td.Broker != "" && td.Established > 0 {
+		peers[td.Broker] = td.Established
+	}
+	return peers
+}
+
+func (group *MeshVPNPeerGroup) addPeers(peers map[string]float64, now time.Time) {
+	if group == nil {
+		return
+	}
+	addPeers(peers, group.Peers, now)
+	for _, subgroup := range group.Groups {
+		subgroup.addPeers(peers, now)
+	}
+}
+
+func addPeers(peers map[string]float64, links map[string]*MeshVPNPeerLink, now time.Time) {
+	for name, link := range links {
+		if link.IsEstablished(now) {
+			peers[name] = link.Established
+		}
+	}
+}
+
+// IsEstablished returns whether the connection to the peer is established
+func (link *MeshVPNPeerLink) IsEstablished(now time.Time) bool {
+	if link == nil {
+		return false
+	}
+	if link.LatestHandshake > 0 {
+		handshake := time.Unix(int64(link.LatestHandshake), 0)
+		return now.Sub(handshake) < MeshVPNHandshakeTimeout
+	}
+	return link.Established > 1
 }
 
 // Traffic struct
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,6 +21,43 @@ func TestStatistics(t *testing.T) {
 	assert.Equal(uint32(35), obj.Clients.Wifi)
 	assert.Equal(uint32(30), obj.Clients.Wifi24)
 	assert.Equal(uint32(8), obj.Clients.Wifi5)
+
+	peers := obj.MeshVPN.EstablishedPeers(time.Now())
+	assert.Equal(map[string]float64{"vpn06": 138270.378}, peers)
+}
+
+func TestStatisticsWireGuard(t *testing.T) {
+	assert := assert.New(t)
+	obj := &Statistics{}
+	testfile("statistics_wireguard.json", obj)
+
+	assert.Equal("wireguard", obj.MeshVPN.Provider)
+	assert.Len(obj.MeshVPN.Peers, 3)
+	assert.Equal(float64(1700000000), obj.MeshVPN.Peers["vpn01"].LatestHandshake)
+
+	// vpn02 has not done a handshake within the last 3 minutes
+	peers := obj.MeshVPN.EstablishedPeers(time.Unix(1700000000+60, 0))
+	assert.Equal(map[string]float64{"vpn01": 0}, peers)
+
+	peers = obj.MeshVPN.EstablishedPeers(time.Unix(1699999000+30, 0))
+	assert.Len(peers, 2)
+	assert.Equal(float64(3600), peers["vpn02"])
+
+	peers = obj.MeshVPN.EstablishedPeers(time.Unix(1700000000, 0).Add(MeshVPNHandshakeTimeout))
+	assert.Len(peers, 0)
+}
+
+func TestStatisticsTunneldigger(t *testing.T) {
+	assert := assert.New(t)
+	obj := &Statistics{}
+	testfile("statistics_tunneldigger.json", obj)
+
+	assert.Equal("tunneldigger", obj.MeshVPN.Provider)
+	peers := obj.MeshVPN.EstablishedPeers(time.Now())
+	assert.Equal(map[string]float64{"gw01.ffhb.de:10000": 800.5}, peers)
+
+	obj.MeshVPN.Tunneldigger.Established = 0
+	assert.Len(obj.MeshVPN.EstablishedPeers(time.Now()), 0)
 }
 
 func testfile(name string, obj interface{}) {
@@ -0,0 +1,50 @@
+package all
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/expression"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Check validates the configuration of all database connections and returns every problem
+func Check(configuration map[string][]interface{}) (errs []error) {
+	types := make([]string, 0, len(configuration))
+	for dbType := range configuration {
+		types = append(types, dbType)
+	}
+	sort.Strings(types)
+
+	for _, dbType := range types {
+		if _, ok := database.Adapters[dbType]; !ok {
+			errs = append(errs, &runtime.OptionError{Key: "database.connection." + dbType, Msg: "unknown database"})
+			continue
+		}
+		for i, config := range configuration[dbType] {
+			errs = append(errs, validate(dbType, i, config)...)
+		}
+	}
+	return
+}
+
+// validate checks the configuration of a connection and sets its defaults
+func validate(dbType string, i int, config interface{}) []error {
+	options, ok := database.Options[dbType]
+	if !ok {
+		return nil
+	}
+	prefix := fmt.Sprintf("database.connection.%s[%d]", dbType, i)
+	table, ok := config.(map[string]interface{})
+	if !ok {
+		return []error{&runtime.OptionError{Key: prefix, Msg: "expected a table"}}
+	}
+	errs := options.Validate(prefix, table)
+	if source, ok := table["filter"].(string); ok && source != "" {
+		if _, err := expression.Compile(source); err != nil {
+			errs = append(errs, &runtime.OptionError{Key: prefix + ".filter", Msg: err.Error()})
+		}
+	}
+	return errs
+}
@@ -1,61 +1,203 @@
 package all
 
 import (
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/expression"
 	"github.com/FreifunkBremen/yanic/runtime"
 )
 
 type Connection struct {
 	database.Connection
-	list []database.Connection
+	list    []database.Connection
+	entries []*entry
+	sync.RWMutex
+}
+
+// entry is a connection with the configuration it was created from
+type entry struct {
+	key    string // type and index, e.g. influxdb[0]
+	config interface{}
+	conn   database.Connection
+	filter *expression.Expression // nodes to store, nil for all
 }
 
 func Connect(configuration interface{}) (database.Connection, error) {
-	var list []database.Connection
-	allConnection := configuration.(map[string][]interface{})
+	entries, err := connect(configuration.(map[string][]interface{}), nil)
+	if err != nil {
+		return nil, err
+	}
+	conn := &Connection{}
+	conn.set(entries)
+	return conn, nil
+}
+
+// Reload applies a changed configuration, only changed connections are created again
+// and the removed ones are closed. On error the previous connections are kept.
+func (conn *Connection) Reload(configuration map[string][]interface{}) error {
+	conn.RLock()
+	old := conn.entries
+	conn.RUnlock()
+
+	entries, err := connect(configuration, old)
+	if err != nil {
+		// close the already created connections
+		for _, e := range entries {
+			if !contains(old, e) {
+				e.conn.Close()
+			}
+		}
+		return err
+	}
+	// set waits for running inserts into the old connections
+	conn.set(entries)
+
+	for _, e := range old {
+		if !contains(entries, e) {
+			log.Printf("reload: closing database connection %s", e.key)
+			e.conn.Close()
+		}
+	}
+	return nil
+}
+
+// connect creates the connections of the configuration, unchanged ones are taken from the given entries.
+// On error the created entries are returned as well.
+func connect(allConnection map[string][]interface{}, old []*entry) ([]*entry, error) {
+	previous := make(map[string]*entry, len(old))
+	for _, e := range old {
+		previous[e.key] = e
+	}
+
+	var entries []*entry
 	for dbType, conn := range database.Adapters {
 		dbConfigs := allConnection[dbType]
-		for _, config := range dbConfigs {
+		for i, config := range dbConfigs {
+			if table, ok := config.(map[string]interface{}); ok {
+				if c, ok := table["enable"].(bool); ok && !c {
+					continue
+				}
+			}
+			if errs := validate(dbType, i, config); len(errs) > 0 {
+				return entries, errs[0]
+			}
+			key := fmt.Sprintf("%s[%d]", dbType, i)
+			if p := previous[key]; p != nil && reflect.DeepEqual(p.config, config) {
+				entries = append(entries, p)
+				continue
+			}
+			if old != nil {
+				log.Printf("reload: connecting database %s", key)
+			}
+			var filter *expression.Expression
+			if table, ok := config.(map[string]interface{}); ok {
+				if source, ok := table["filter"].(string); ok && source != "" {
+					var err error
+					if filter, err = expression.Compile(source); err != nil {
+						return entries, err
+					}
+				}
+			}
 			connected, err := conn(config)
 			if err != nil {
-				return nil, err
+				return entries, err
 			}
 			if connected == nil {
 				continue
 			}
-			list = append(list, connected)
+			entries = append(entries, &entry{key: key, config: config, conn: connected, filter: filter})
+		}
+	}
+	return entries, nil
+}
+
+func contains(entries []*entry, e *entry) bool {
+	for _, item := range entries {
+		if item == e {
+			return true
 		}
 	}
-	return &Connection{list: list}, nil
+	return false
+}
+
+func (conn *Connection) set(entries []*entry) {
+	list := make([]database.Connection, len(entries))
+	for i, e := range entries {
+		list[i] = e.conn
+	}
+	conn.Lock()
+	conn.entries = entries
+	conn.list = list
+	conn.Unlock()
 }
 
 func (conn *Connection) InsertNode(node *runtime.Node) {
-	for _, item := range conn.list {
-		item.InsertNode(node)
+	conn.RLock()
+	defer conn.RUnlock()
+	for _, e := range conn.entries {
+		if e.filter == nil || e.filter.Match(node) {
+			e.conn.InsertNode(node)
+		}
 	}
 }
 
+// InsertLink stores the link in the connections without filter, the node reporting it is unknown
 func (conn *Connection) InsertLink(link *runtime.Link, time time.Time) {
+	conn.RLock()
+	defer conn.RUnlock()
+	for _, e := range conn.entries {
+		if e.filter == nil {
+			e.conn.InsertLink(link, time)
+		}
+	}
+}
+
+// InsertNodeLinks stores the links in the connections, whose filter matches the node reporting them
+func (conn *Connection) InsertNodeLinks(node *runtime.Node, links []runtime.Link, time time.Time) {
+	conn.RLock()
+	defer conn.RUnlock()
+	for _, e := range conn.entries {
+		if e.filter != nil && !e.filter.Match(node) {
+			continue
+		}
+		for i := range links {
+			e.conn.InsertLink(&links[i], time)
+		}
+	}
+}
+
+func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string, domain string) {
+	conn.RLock()
+	defer conn.RUnlock()
 	for _, item := range conn.list {
-		item.InsertLink(link, time)
+		item.InsertGlobals(stats, time, site, domain)
 	}
 }
 
-func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string) {
+func (conn *Connection) InsertCollectorStats(stats runtime.CollectorStats, time time.Time) {
+	conn.RLock()
+	defer conn.RUnlock()
 	for _, item := range conn.list {
-		item.InsertGlobals(stats, time, site)
+		item.InsertCollectorStats(stats, time)
 	}
 }
 
 func (conn *Connection) PruneNodes(deleteAfter time.Duration) {
+	conn.RLock()
+	defer conn.RUnlock()
 	for _, item := range conn.list {
 		item.PruneNodes(deleteAfter)
 	}
 }
 
 func (conn *Connection) Close() {
+	conn.RLock()
+	defer conn.RUnlock()
 	for _, item := range conn.list {
 		item.Close()
 	}
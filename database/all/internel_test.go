@@ -1 +1,136 @@
 package all
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConn struct {
+	database.Connection
+	nodes  int
+	links  int
+	closed bool
+}
+
+func (c *testConn) InsertNode(node *runtime.Node) {
+	c.nodes++
+}
+
+func (c *testConn) InsertLink(link *runtime.Link, t time.Time) {
+	c.links++
+}
+
+func (c *testConn) Close() {
+	c.closed = true
+}
+
+func TestReload(t *testing.T) {
+	assert := assert.New(t)
+
+	var created []*testConn
+	database.RegisterAdapter("reload", func(config interface{}) (database.Connection, error) {
+		if config.(map[string]interface{})["fail"] == true {
+			return nil, errors.New("unable to connect")
+		}
+		conn := &testConn{}
+		created = append(created, conn)
+		return conn, nil
+	})
+	defer delete(database.Adapters, "reload")
+
+	c, err := Connect(map[string][]interface{}{
+		"reload": {
+			map[string]interface{}{"address": "a"},
+			map[string]interface{}{"address": "b"},
+		},
+	})
+	assert.NoError(err)
+	conn := c.(*Connection)
+	assert.Len(created, 2)
+
+	// the second connection changed
+	assert.NoError(conn.Reload(map[string][]interface{}{
+		"reload": {
+			map[string]interface{}{"address": "a"},
+			map[string]interface{}{"address": "c"},
+		},
+	}))
+	assert.Len(created, 3)
+	assert.False(created[0].closed)
+	assert.True(created[1].closed)
+
+	conn.InsertNode(&runtime.Node{})
+	assert.Equal(1, created[0].nodes)
+	assert.Equal(0, created[1].nodes)
+	assert.Equal(1, created[2].nodes)
+
+	// on error the previous connections are kept
+	assert.Error(conn.Reload(map[string][]interface{}{
+		"reload": {
+			map[string]interface{}{"address": "a"},
+			map[string]interface{}{"address": "d"},
+			map[string]interface{}{"fail": true},
+		},
+	}))
+	assert.Len(created, 4)
+	assert.True(created[3].closed)
+	assert.False(created[2].closed)
+	conn.InsertNode(&runtime.Node{})
+	assert.Equal(2, created[2].nodes)
+
+	conn.Close()
+	assert.True(created[0].closed)
+	assert.True(created[2].closed)
+}
+
+func TestFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	var created []*testConn
+	database.RegisterAdapter("filter", func(config interface{}) (database.Connection, error) {
+		conn := &testConn{}
+		created = append(created, conn)
+		return conn, nil
+	})
+	defer delete(database.Adapters, "filter")
+
+	_, err := Connect(map[string][]interface{}{
+		"filter": {
+			map[string]interface{}{"filter": "online &&"},
+		},
+	})
+	assert.EqualError(err, "position 10: unexpected end of expression")
+
+	c, err := Connect(map[string][]interface{}{
+		"filter": {
+			map[string]interface{}{},
+			map[string]interface{}{"filter": "online"},
+		},
+	})
+	assert.NoError(err)
+	conn := c.(*Connection)
+	assert.Len(created, 2)
+
+	conn.InsertNode(&runtime.Node{Online: true})
+	conn.InsertNode(&runtime.Node{Online: false})
+	assert.Equal(2, created[0].nodes)
+	assert.Equal(1, created[1].nodes)
+
+	// links are filtered by the node reporting them
+	links := []runtime.Link{{SourceID: "a", TargetID: "b"}, {SourceID: "a", TargetID: "c"}}
+	database.InsertNodeLinks(conn, &runtime.Node{Online: true}, links, time.Now())
+	database.InsertNodeLinks(conn, &runtime.Node{Online: false}, links, time.Now())
+	assert.Equal(4, created[0].links)
+	assert.Equal(2, created[1].links)
+
+	// without the node only into the connections without filter
+	conn.InsertLink(&links[0], time.Now())
+	assert.Equal(5, created[0].links)
+	assert.Equal(2, created[1].links)
+	conn.Close()
+}
@@ -14,8 +14,11 @@ type Connection interface {
 	// InsertLink stores statistics per link
 	InsertLink(*runtime.Link, time.Time)
 
-	// InsertGlobals stores global statistics
-	InsertGlobals(*runtime.GlobalStats, time.Time, string)
+	// InsertGlobals stores global statistics of a site and domain
+	InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string, domain string)
+
+	// InsertCollectorStats stores the self-metrics of the collector
+	InsertCollectorStats(runtime.CollectorStats, time.Time)
 
 	// PruneNodes prunes historical per-node data
 	PruneNodes(deleteAfter time.Duration)
@@ -24,12 +27,42 @@ type Connection interface {
 	Close()
 }
 
+// LinkInserter is implemented by connections, which store the links depending on the node reporting them
+type LinkInserter interface {
+	// InsertNodeLinks stores the links reported by the node
+	InsertNodeLinks(node *runtime.Node, links []runtime.Link, time time.Time)
+}
+
+// InsertNodeLinks stores the links reported by the node
+func InsertNodeLinks(conn Connection, node *runtime.Node, links []runtime.Link, time time.Time) {
+	if inserter, ok := conn.(LinkInserter); ok {
+		inserter.InsertNodeLinks(node, links, time)
+		return
+	}
+	for i := range links {
+		conn.InsertLink(&links[i], time)
+	}
+}
+
 // Connect function with config to get DB connection interface
 type Connect func(config interface{}) (Connection, error)
 
 // Adapters is the list of registered database adapters
 var Adapters = map[string]Connect{}
 
-func RegisterAdapter(name string, n Connect) {
+// Options of the registered database adapters, which declared them
+var Options = map[string]runtime.Options{}
+
+// CommonOptions are the options of every database
+var CommonOptions = runtime.Options{
+	{Name: "enable", Type: runtime.OptionBool, Default: true},
+	{Name: "filter", Type: runtime.OptionString, Doc: "Store only the nodes matching the expression, e.g. site_code == \"ffhb\""},
+}
+
+// RegisterAdapter registers a database, its configuration is validated against the options if given
+func RegisterAdapter(name string, n Connect, options ...runtime.Option) {
 	Adapters[name] = n
+	if len(options) > 0 {
+		Options[name] = append(append(runtime.Options{}, CommonOptions...), options...)
+	}
 }
@@ -5,15 +5,21 @@ import (
 	"sync"
 
 	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/runtime"
 	"github.com/fgrosse/graphigo"
 )
 
 const (
-	MeasurementNode               = "node"        // Measurement for per-node statistics
-	MeasurementGlobal             = "global"      // Measurement for summarized global statistics
-	CounterMeasurementFirmware    = "firmware"    // Measurement for firmware statistics
-	CounterMeasurementModel       = "model"       // Measurement for model statistics
-	CounterMeasurementAutoupdater = "autoupdater" // Measurement for autoupdater
+	MeasurementNode                = "node"          // Measurement for per-node statistics
+	MeasurementGlobal              = "global"        // Measurement for summarized global statistics
+	MeasurementYanic               = "yanic"         // Measurement for self-metrics of the collector
+	CounterMeasurementFirmware     = "firmware"      // Measurement for firmware statistics
+	CounterMeasurementModel        = "model"         // Measurement for model statistics
+	CounterMeasurementAutoupdater  = "autoupdater"   // Measurement for autoupdater
+	CounterMeasurementFirmwareBase = "firmware_base" // Measurement for firmware base statistics
+	CounterMeasurementBatmanAdv    = "batadv"        // Measurement for batman-adv versions
+	CounterMeasurementNproc        = "nproc"         // Measurement for the number of processors
+	CounterMeasurementMemory       = "memory"        // Measurement for memory classes
 )
 
 type Connection struct {
@@ -30,11 +36,13 @@ func (c Config) Address() string {
 }
 
 func (c Config) Prefix() string {
-	return c["prefix"].(string)
+	prefix, _ := c["prefix"].(string)
+	return prefix
 }
 
 func (c Config) Enable() bool {
-	return c["enable"].(bool)
+	enable, ok := c["enable"].(bool)
+	return enable || !ok
 }
 
 func Connect(configuration interface{}) (database.Connection, error) {
@@ -88,5 +96,8 @@ func (c *Connection) addPoint(point []graphigo.Metric) {
 }
 
 func init() {
-	database.RegisterAdapter("graphite", Connect)
+	database.RegisterAdapter("graphite", Connect,
+		runtime.Option{Name: "address", Type: runtime.OptionString, Required: true, Doc: "Address of the carbon receiver, e.g. localhost:2003"},
+		runtime.Option{Name: "prefix", Type: runtime.OptionString, Default: "freifunk", Doc: "Prefix of all metrics"},
+	)
 }
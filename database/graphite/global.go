@@ -7,34 +7,61 @@ import (
 	"github.com/fgrosse/graphigo"
 )
 
-func (c *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string) {
-	measurementGlobal := MeasurementGlobal
-	counterMeasurementModel := CounterMeasurementModel
-	counterMeasurementFirmware := CounterMeasurementFirmware
-	counterMeasurementAutoupdater := CounterMeasurementAutoupdater
+func (c *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string, domain string) {
+	counters := map[string]runtime.CounterMap{
+		CounterMeasurementModel:        stats.Models,
+		CounterMeasurementFirmware:     stats.Firmwares,
+		CounterMeasurementFirmwareBase: stats.FirmwareBases,
+		CounterMeasurementAutoupdater:  stats.Autoupdater,
+		CounterMeasurementBatmanAdv:    stats.BatmanAdv,
+		CounterMeasurementNproc:        stats.Nproc,
+		CounterMeasurementMemory:       stats.Memory,
+	}
 
-	if site != runtime.GLOBAL_SITE {
-		measurementGlobal += "_" + site
-		counterMeasurementModel += "_" + site
-		counterMeasurementFirmware += "_" + site
-		counterMeasurementAutoupdater += "_" + site
+	suffix := ""
+	if domain != runtime.GLOBAL_DOMAIN {
+		// e.g. global_ffhb_city or global_global_city for a domain of all sites
+		suffix = "_" + site + "_" + domain
+	} else if site != runtime.GLOBAL_SITE {
+		suffix = "_" + site
 	}
 
-	c.addPoint(GlobalStatsFields(measurementGlobal, stats))
-	c.addCounterMap(counterMeasurementModel, stats.Models, time)
-	c.addCounterMap(counterMeasurementFirmware, stats.Firmwares, time)
-	c.addCounterMap(counterMeasurementAutoupdater, stats.Autoupdater, time)
+	c.addPoint(GlobalStatsFields(MeasurementGlobal+suffix, stats))
+	for measurement, counter := range counters {
+		c.addCounterMap(measurement+suffix, counter, time)
+	}
 }
 
 func GlobalStatsFields(name string, stats *runtime.GlobalStats) []graphigo.Metric {
-	return []graphigo.Metric{
+	fields := []graphigo.Metric{
 		{Name: name + ".nodes", Value: stats.Nodes},
 		{Name: name + ".gateways", Value: stats.Gateways},
 		{Name: name + ".clients.total", Value: stats.Clients},
 		{Name: name + ".clients.wifi", Value: stats.ClientsWifi},
 		{Name: name + ".clients.wifi24", Value: stats.ClientsWifi24},
 		{Name: name + ".clients.wifi5", Value: stats.ClientsWifi5},
+		{Name: name + ".nodes_known", Value: stats.Known},
+		{Name: name + ".nodes_offline", Value: stats.Offline},
+		{Name: name + ".nodes_new", Value: stats.New},
+		{Name: name + ".nodes_with_location", Value: stats.NodesWithLocation},
+		{Name: name + ".nodes_without_location", Value: stats.NodesWithoutLocation},
+		{Name: name + ".nodes_vpn", Value: stats.NodesVPN},
+		{Name: name + ".nodes_mesh_only", Value: stats.NodesMeshOnly},
+		{Name: name + ".rate.rx.bytes", Value: stats.Traffic.Rx},
+		{Name: name + ".rate.tx.bytes", Value: stats.Traffic.Tx},
+		{Name: name + ".rate.forward.bytes", Value: stats.Traffic.Forward},
+		{Name: name + ".rate.mgmt_rx.bytes", Value: stats.Traffic.MgmtRx},
+		{Name: name + ".rate.mgmt_tx.bytes", Value: stats.Traffic.MgmtTx},
+	}
+	for frequency, airtime := range stats.Airtime {
+		fields = append(fields,
+			graphigo.Metric{Name: name + ".airtime" + frequency + ".radios", Value: airtime.Radios},
+			graphigo.Metric{Name: name + ".airtime" + frequency + ".chan_util", Value: airtime.ChanUtil},
+			graphigo.Metric{Name: name + ".airtime" + frequency + ".rx_util", Value: airtime.RxUtil},
+			graphigo.Metric{Name: name + ".airtime" + frequency + ".tx_util", Value: airtime.TxUtil},
+		)
 	}
+	return fields
 }
 
 func (c *Connection) addCounterMap(name string, m runtime.CounterMap, t time.Time) {
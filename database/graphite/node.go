@@ -31,16 +31,7 @@ func (c *Connection) InsertNode(node *runtime.Node) {
 	}
 
 	if neighbours := node.Neighbours; neighbours != nil {
-		vpn := 0
-		if meshvpn := stats.MeshVPN; meshvpn != nil {
-			for _, group := range meshvpn.Groups {
-				for _, link := range group.Peers {
-					if link != nil && link.Established > 1 {
-						vpn++
-					}
-				}
-			}
-		}
+		vpn := len(node.MeshVPNPeers())
 		addField("neighbours.vpn", vpn)
 		// protocol: Batman Advance
 		batadv := 0
@@ -60,6 +51,11 @@ func (c *Connection) InsertNode(node *runtime.Node) {
 		addField("neighbours.total", batadv+lldp)
 	}
 
+	// uptime of the mesh VPN connection per peer
+	for peer, uptime := range node.MeshVPNPeers() {
+		addField("mesh_vpn."+replaceInvalidChars(peer)+".uptime", int64(uptime))
+	}
+
 	if t := stats.Traffic.Rx; t != nil {
 		addField("traffic.rx.bytes", int64(t.Bytes))
 		addField("traffic.rx.packets", t.Packets)
@@ -82,6 +78,21 @@ func (c *Connection) InsertNode(node *runtime.Node) {
 		addField("traffic.mgmt_tx.packets", t.Packets)
 	}
 
+	// rates since the previous statistics
+	if rates := stats.Rates; rates != nil {
+		for name, rate := range rates.Traffic() {
+			addField("rate."+name+".bytes", rate.Bytes)
+			addField("rate."+name+".packets", rate.Packets)
+			addField("rate."+name+".dropped", rate.Dropped)
+		}
+		if ratio := rates.ForwardRatio; ratio != nil {
+			addField("rate.forward_ratio", *ratio)
+		}
+		if usage := rates.CPUUsage; usage != nil {
+			addField("cpu.usage", *usage)
+		}
+	}
+
 	for _, airtime := range stats.Wireless {
 		suffix := airtime.FrequencyName()
 		addField("airtime"+suffix+".chan_util", airtime.ChanUtil)
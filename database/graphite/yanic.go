@@ -0,0 +1,30 @@
+package graphite
+
+import (
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/fgrosse/graphigo"
+)
+
+// InsertCollectorStats stores the self-metrics of the collector per interface
+func (c *Connection) InsertCollectorStats(stats runtime.CollectorStats, t time.Time) {
+	for iface, s := range stats {
+		c.addPoint(CollectorStatsFields(MeasurementYanic+"."+replaceInvalidChars(iface), s, t))
+	}
+}
+
+func CollectorStatsFields(name string, stats *runtime.InterfaceStats, t time.Time) []graphigo.Metric {
+	return []graphigo.Metric{
+		{Name: name + ".received", Value: stats.Received, Timestamp: t},
+		{Name: name + ".parse_errors", Value: stats.ParseErrors, Timestamp: t},
+		{Name: name + ".truncated", Value: stats.Truncated, Timestamp: t},
+		{Name: name + ".invalid_node_id", Value: stats.InvalidNodeID, Timestamp: t},
+		{Name: name + ".queue_overflow", Value: stats.QueueOverflow, Timestamp: t},
+		{Name: name + ".unicast.targets", Value: stats.UnicastTargets, Timestamp: t},
+		{Name: name + ".unicast.answered", Value: stats.UnicastAnswered, Timestamp: t},
+		{Name: name + ".responses", Value: stats.Responses, Timestamp: t},
+		{Name: name + ".latency.avg", Value: stats.LatencyAvgS, Timestamp: t},
+		{Name: name + ".latency.max", Value: stats.LatencyMaxS, Timestamp: t},
+	}
+}
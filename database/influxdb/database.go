@@ -9,17 +9,23 @@ import (
 	"github.com/influxdata/influxdb/models"
 
 	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/runtime"
 )
 
 const (
-	MeasurementLink               = "link"        // Measurement for per-link statistics
-	MeasurementNode               = "node"        // Measurement for per-node statistics
-	MeasurementGlobal             = "global"      // Measurement for summarized global statistics
-	CounterMeasurementFirmware    = "firmware"    // Measurement for firmware statistics
-	CounterMeasurementModel       = "model"       // Measurement for model statistics
-	CounterMeasurementAutoupdater = "autoupdater" // Measurement for autoupdater
-	batchMaxSize                  = 500
-	batchTimeout                  = 5 * time.Second
+	MeasurementLink                = "link"          // Measurement for per-link statistics
+	MeasurementNode                = "node"          // Measurement for per-node statistics
+	MeasurementGlobal              = "global"        // Measurement for summarized global statistics
+	MeasurementYanic               = "yanic"         // Measurement for self-metrics of the collector
+	CounterMeasurementFirmware     = "firmware"      // Measurement for firmware statistics
+	CounterMeasurementModel        = "model"         // Measurement for model statistics
+	CounterMeasurementAutoupdater  = "autoupdater"   // Measurement for autoupdater
+	CounterMeasurementFirmwareBase = "firmware_base" // Measurement for firmware base statistics
+	CounterMeasurementBatmanAdv    = "batadv"        // Measurement for batman-adv versions
+	CounterMeasurementNproc        = "nproc"         // Measurement for the number of processors
+	CounterMeasurementMemory       = "memory"        // Measurement for memory classes
+	batchMaxSize                   = 500
+	batchTimeout                   = 5 * time.Second
 )
 
 type Connection struct {
@@ -33,7 +39,8 @@ type Connection struct {
 type Config map[string]interface{}
 
 func (c Config) Enable() bool {
-	return c["enable"].(bool)
+	enable, ok := c["enable"].(bool)
+	return enable || !ok
 }
 func (c Config) Address() string {
 	return c["address"].(string)
@@ -42,10 +49,12 @@ func (c Config) Database() string {
 	return c["database"].(string)
 }
 func (c Config) Username() string {
-	return c["username"].(string)
+	username, _ := c["username"].(string)
+	return username
 }
 func (c Config) Password() string {
-	return c["password"].(string)
+	password, _ := c["password"].(string)
+	return password
 }
 func (c Config) Tags() map[string]interface{} {
 	if c["tags"] != nil {
@@ -55,7 +64,13 @@ func (c Config) Tags() map[string]interface{} {
 }
 
 func init() {
-	database.RegisterAdapter("influxdb", Connect)
+	database.RegisterAdapter("influxdb", Connect,
+		runtime.Option{Name: "address", Type: runtime.OptionString, Required: true, Doc: "URL of the InfluxDB, e.g. http://localhost:8086"},
+		runtime.Option{Name: "database", Type: runtime.OptionString, Required: true},
+		runtime.Option{Name: "username", Type: runtime.OptionString, Default: ""},
+		runtime.Option{Name: "password", Type: runtime.OptionString, Default: ""},
+		runtime.Option{Name: "tags", Type: runtime.OptionTable, Doc: "Additional tags of all points, tags used by yanic take precedence"},
+	)
 }
 func Connect(configuration interface{}) (database.Connection, error) {
 	var config Config
@@ -8,56 +8,81 @@ import (
 )
 
 // InsertGlobals implementation of database
-func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string) {
+func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string, domain string) {
 	var tags models.Tags
 
 	measurementGlobal := MeasurementGlobal
-	counterMeasurementModel := CounterMeasurementModel
-	counterMeasurementFirmware := CounterMeasurementFirmware
-	counterMeasurementAutoupdater := CounterMeasurementAutoupdater
+	counters := map[string]runtime.CounterMap{
+		CounterMeasurementModel:        stats.Models,
+		CounterMeasurementFirmware:     stats.Firmwares,
+		CounterMeasurementFirmwareBase: stats.FirmwareBases,
+		CounterMeasurementAutoupdater:  stats.Autoupdater,
+		CounterMeasurementBatmanAdv:    stats.BatmanAdv,
+		CounterMeasurementNproc:        stats.Nproc,
+		CounterMeasurementMemory:       stats.Memory,
+	}
 
+	suffix := ""
 	if site != runtime.GLOBAL_SITE {
-		tags = models.Tags{
-			models.Tag{Key: []byte("site"), Value: []byte(site)},
-		}
-
-		measurementGlobal += "_site"
-		counterMeasurementModel += "_site"
-		counterMeasurementFirmware += "_site"
-		counterMeasurementAutoupdater += "_site"
+		tags = append(tags, models.Tag{Key: []byte("site"), Value: []byte(site)})
+		suffix += "_site"
+	}
+	if domain != runtime.GLOBAL_DOMAIN {
+		tags = append(tags, models.Tag{Key: []byte("domain"), Value: []byte(domain)})
+		suffix += "_domain"
 	}
 
-	conn.addPoint(measurementGlobal, tags, GlobalStatsFields(stats), time)
-	conn.addCounterMap(counterMeasurementModel, stats.Models, time, site)
-	conn.addCounterMap(counterMeasurementFirmware, stats.Firmwares, time, site)
-	conn.addCounterMap(counterMeasurementAutoupdater, stats.Autoupdater, time, site)
+	conn.addPoint(measurementGlobal+suffix, tags, GlobalStatsFields(stats), time)
+	for measurement, counter := range counters {
+		conn.addCounterMap(measurement+suffix, counter, time, site, domain)
+	}
 }
 
 // GlobalStatsFields returns fields for InfluxDB
 func GlobalStatsFields(stats *runtime.GlobalStats) map[string]interface{} {
-	return map[string]interface{}{
+	fields := map[string]interface{}{
 		"nodes":          stats.Nodes,
 		"gateways":       stats.Gateways,
 		"clients.total":  stats.Clients,
 		"clients.wifi":   stats.ClientsWifi,
 		"clients.wifi24": stats.ClientsWifi24,
 		"clients.wifi5":  stats.ClientsWifi5,
+
+		"nodes_known":            stats.Known,
+		"nodes_offline":          stats.Offline,
+		"nodes_new":              stats.New,
+		"nodes_with_location":    stats.NodesWithLocation,
+		"nodes_without_location": stats.NodesWithoutLocation,
+		"nodes_vpn":              stats.NodesVPN,
+		"nodes_mesh_only":        stats.NodesMeshOnly,
+
+		"rate.rx.bytes":      stats.Traffic.Rx,
+		"rate.tx.bytes":      stats.Traffic.Tx,
+		"rate.forward.bytes": stats.Traffic.Forward,
+		"rate.mgmt_rx.bytes": stats.Traffic.MgmtRx,
+		"rate.mgmt_tx.bytes": stats.Traffic.MgmtTx,
+	}
+	for frequency, airtime := range stats.Airtime {
+		fields["airtime"+frequency+".radios"] = airtime.Radios
+		fields["airtime"+frequency+".chan_util"] = airtime.ChanUtil
+		fields["airtime"+frequency+".rx_util"] = airtime.RxUtil
+		fields["airtime"+frequency+".tx_util"] = airtime.TxUtil
 	}
+	return fields
 }
 
 // Saves the values of a CounterMap in the database.
 // The key are used as 'value' tag.
 // The value is used as 'counter' field.
-func (conn *Connection) addCounterMap(name string, m runtime.CounterMap, t time.Time, site string) {
+func (conn *Connection) addCounterMap(name string, m runtime.CounterMap, t time.Time, site string, domain string) {
 	for key, count := range m {
-		conn.addPoint(
-			name,
-			models.Tags{
-				models.Tag{Key: []byte("value"), Value: []byte(key)},
-				models.Tag{Key: []byte("site"), Value: []byte(site)},
-			},
-			models.Fields{"count": count},
-			t,
-		)
+		tags := models.Tags{
+			models.Tag{Key: []byte("value"), Value: []byte(key)},
+			models.Tag{Key: []byte("site"), Value: []byte(site)},
+		}
+		if domain != runtime.GLOBAL_DOMAIN {
+			tags = append(tags, models.Tag{Key: []byte("domain"), Value: []byte(domain)})
+		}
+		conn.addPoint(name, tags, models.Fields{"count": count}, t)
 	}
 }
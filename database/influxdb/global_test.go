@@ -12,16 +12,18 @@ import (
 const TEST_SITE = "ffxx"
 
 func TestGlobalStats(t *testing.T) {
-	stats := runtime.NewGlobalStats(createTestNodes(), []string{TEST_SITE})
+	stats := runtime.NewGlobalStats(createTestNodes(), []string{TEST_SITE}, nil)
 
 	assert := assert.New(t)
 
 	// check SITE_GLOBAL fields
-	fields := GlobalStatsFields(stats[runtime.GLOBAL_SITE])
+	fields := GlobalStatsFields(stats[runtime.GLOBAL_SITE][runtime.GLOBAL_DOMAIN])
 	assert.EqualValues(3, fields["nodes"])
+	assert.EqualValues(3, fields["nodes_known"])
+	assert.EqualValues(0, fields["nodes_offline"])
 
 	// check TEST_SITE fields
-	fields = GlobalStatsFields(stats[TEST_SITE])
+	fields = GlobalStatsFields(stats[TEST_SITE][runtime.GLOBAL_DOMAIN])
 	assert.EqualValues(2, fields["nodes"])
 }
 
@@ -47,11 +47,18 @@ func (conn *Connection) InsertNode(node *runtime.Node) {
 		"memory.total":   stats.Memory.Total,
 	}
 
+	if node.Maintenance {
+		fields["maintenance"] = true
+	}
+
 	if nodeinfo := node.Nodeinfo; nodeinfo != nil {
 		tags.SetString("hostname", nodeinfo.Hostname)
 		if nodeinfo.System.SiteCode != "" {
 			tags.SetString("site", nodeinfo.System.SiteCode)
 		}
+		if nodeinfo.System.DomainCode != "" {
+			tags.SetString("domain", nodeinfo.System.DomainCode)
+		}
 		if owner := nodeinfo.Owner; owner != nil {
 			tags.SetString("owner", owner.Contact)
 		}
@@ -73,16 +80,7 @@ func (conn *Connection) InsertNode(node *runtime.Node) {
 
 	if neighbours := node.Neighbours; neighbours != nil {
 		// VPN Neighbours are Neighbours but includet in one protocol
-		vpn := 0
-		if meshvpn := stats.MeshVPN; meshvpn != nil {
-			for _, group := range meshvpn.Groups {
-				for _, link := range group.Peers {
-					if link != nil && link.Established > 1 {
-						vpn++
-					}
-				}
-			}
-		}
+		vpn := len(node.MeshVPNPeers())
 		fields["neighbours.vpn"] = vpn
 
 		// protocol: Batman Advance
@@ -103,6 +101,11 @@ func (conn *Connection) InsertNode(node *runtime.Node) {
 		fields["neighbours.total"] = batadv + lldp
 	}
 
+	// uptime of the mesh VPN connection per peer
+	for peer, uptime := range node.MeshVPNPeers() {
+		fields["mesh_vpn."+peer+".uptime"] = int64(uptime)
+	}
+
 	if t := stats.Traffic.Rx; t != nil {
 		fields["traffic.rx.bytes"] = int64(t.Bytes)
 		fields["traffic.rx.packets"] = t.Packets
@@ -125,6 +128,21 @@ func (conn *Connection) InsertNode(node *runtime.Node) {
 		fields["traffic.mgmt_tx.packets"] = t.Packets
 	}
 
+	// rates since the previous statistics
+	if rates := stats.Rates; rates != nil {
+		for name, rate := range rates.Traffic() {
+			fields["rate."+name+".bytes"] = rate.Bytes
+			fields["rate."+name+".packets"] = rate.Packets
+			fields["rate."+name+".dropped"] = rate.Dropped
+		}
+		if ratio := rates.ForwardRatio; ratio != nil {
+			fields["rate.forward_ratio"] = *ratio
+		}
+		if usage := rates.CPUUsage; usage != nil {
+			fields["cpu.usage"] = *usage
+		}
+	}
+
 	for _, airtime := range stats.Wireless {
 		suffix := airtime.FrequencyName()
 		fields["airtime"+suffix+".chan_util"] = airtime.ChanUtil
@@ -33,6 +33,9 @@ func TestToInflux(t *testing.T) {
 				MgmtTx:  &data.Traffic{Packets: 2327},
 				MgmtRx:  &data.Traffic{Bytes: 2331},
 			},
+			Rates: &data.Rates{
+				Rx: &data.TrafficRate{Bytes: 1024, Packets: 2},
+			},
 			MeshVPN: &data.MeshVPN{
 				Groups: map[string]*data.MeshVPNPeerGroup{
 					"ffhb": &data.MeshVPNPeerGroup{
@@ -136,6 +139,8 @@ func TestToInflux(t *testing.T) {
 	assert.EqualValues(0, fields["neighbours.lldp"])
 	assert.EqualValues(1, fields["neighbours.batadv"])
 	assert.EqualValues(1, fields["neighbours.vpn"])
+	assert.EqualValues(int64(3), fields["mesh_vpn.vpn01.uptime"])
+	assert.NotContains(fields, "mesh_vpn.vpn02.uptime")
 	assert.EqualValues(1, fields["neighbours.total"])
 
 	assert.EqualValues(uint32(3), fields["wireless.txpower24"])
@@ -147,6 +152,9 @@ func TestToInflux(t *testing.T) {
 	assert.EqualValues(int64(1322), fields["traffic.forward.bytes"])
 	assert.EqualValues(int64(2331), fields["traffic.mgmt_rx.bytes"])
 	assert.EqualValues(float64(2327), fields["traffic.mgmt_tx.packets"])
+	assert.EqualValues(float64(1024), fields["rate.rx.bytes"])
+	assert.NotContains(fields, "rate.tx.bytes")
+	assert.NotContains(fields, "cpu.usage")
 
 	// second point contains the link
 	nPoint := points[1]
@@ -0,0 +1,34 @@
+package influxdb
+
+import (
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/influxdata/influxdb/models"
+)
+
+// InsertCollectorStats stores the self-metrics of the collector per interface
+func (conn *Connection) InsertCollectorStats(stats runtime.CollectorStats, t time.Time) {
+	for iface, s := range stats {
+		tags := models.Tags{}
+		tags.SetString("interface", iface)
+
+		conn.addPoint(MeasurementYanic, tags, CollectorStatsFields(s), t)
+	}
+}
+
+// CollectorStatsFields returns fields for InfluxDB
+func CollectorStatsFields(stats *runtime.InterfaceStats) map[string]interface{} {
+	return map[string]interface{}{
+		"received":         int64(stats.Received),
+		"parse_errors":     int64(stats.ParseErrors),
+		"truncated":        int64(stats.Truncated),
+		"invalid_node_id":  int64(stats.InvalidNodeID),
+		"queue_overflow":   int64(stats.QueueOverflow),
+		"unicast.targets":  int64(stats.UnicastTargets),
+		"unicast.answered": int64(stats.UnicastAnswered),
+		"responses":        int64(stats.Responses),
+		"latency.avg":      stats.LatencyAvgS,
+		"latency.max":      stats.LatencyMaxS,
+	}
+}
@@ -0,0 +1,26 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestCollectorStats(t *testing.T) {
+	assert := assert.New(t)
+
+	stats := &runtime.InterfaceStats{
+		Received:      10,
+		QueueOverflow: 2,
+	}
+	stats.AddLatency(time.Second)
+
+	fields := CollectorStatsFields(stats)
+	assert.EqualValues(10, fields["received"])
+	assert.EqualValues(2, fields["queue_overflow"])
+	assert.EqualValues(1, fields["responses"])
+	assert.Equal(1.0, fields["latency.avg"])
+}
@@ -1,24 +1,30 @@
 package database
 
 import (
+	"sync"
 	"time"
 
 	"github.com/FreifunkBremen/yanic/runtime"
 )
 
 var quit chan struct{}
+var wg = sync.WaitGroup{}
 
 // Start workers of database
 // WARNING: Do not override this function
 //  you should use New()
 func Start(conn Connection, config *runtime.Config) {
 	quit = make(chan struct{})
-	go deleteWorker(conn, config.Database.DeleteInterval.Duration, config.Database.DeleteAfter.Duration)
+	wg.Add(1)
+	go deleteWorker(conn, config.Database.DeleteInterval.Duration, config.Database.DeleteAfter.Duration, quit)
 }
 
+// Close stops the workers, it waits for a running prune
 func Close(conn Connection) {
 	if quit != nil {
 		close(quit)
+		wg.Wait()
+		quit = nil
 	}
 	if conn != nil {
 		conn.Close()
@@ -26,7 +32,8 @@ func Close(conn Connection) {
 }
 
 // prunes node-specific data periodically
-func deleteWorker(conn Connection, deleteInterval time.Duration, deleteAfter time.Duration) {
+func deleteWorker(conn Connection, deleteInterval time.Duration, deleteAfter time.Duration, quit <-chan struct{}) {
+	defer wg.Done()
 	ticker := time.NewTicker(deleteInterval)
 	for {
 		select {
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+type pruneConn struct {
+	Connection
+	sync.Mutex
+	prunes int
+}
+
+func (c *pruneConn) PruneNodes(time.Duration) {
+	c.Lock()
+	c.prunes++
+	c.Unlock()
+	time.Sleep(5 * time.Millisecond)
+}
+
+func (c *pruneConn) count() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.prunes
+}
+
+func TestStartClose(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &runtime.Config{}
+	config.Database.DeleteInterval.Duration = time.Millisecond
+	conn := &pruneConn{}
+
+	// restarted like on a reload
+	Start(conn, config)
+	time.Sleep(10 * time.Millisecond)
+	Close(nil)
+	Start(conn, config)
+	time.Sleep(10 * time.Millisecond)
+	Close(nil)
+
+	// no worker is left
+	count := conn.count()
+	assert.NotZero(count)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(count, conn.count())
+}
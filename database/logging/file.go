@@ -24,14 +24,17 @@ type Connection struct {
 type Config map[string]interface{}
 
 func (c Config) Enable() bool {
-	return c["enable"].(bool)
+	enable, ok := c["enable"].(bool)
+	return enable || !ok
 }
 func (c Config) Path() string {
 	return c["path"].(string)
 }
 
 func init() {
-	database.RegisterAdapter("logging", Connect)
+	database.RegisterAdapter("logging", Connect,
+		runtime.Option{Name: "path", Type: runtime.OptionString, Required: true, Doc: "Path of the log file"},
+	)
 }
 
 func Connect(configuration interface{}) (database.Connection, error) {
@@ -56,8 +59,14 @@ func (conn *Connection) InsertLink(link *runtime.Link, time time.Time) {
 	conn.log("InsertLink: ", link)
 }
 
-func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string) {
-	conn.log("InsertGlobals: [", time.String(), "] site: ", site, ", nodes: ", stats.Nodes, ", clients: ", stats.Clients, " models: ", len(stats.Models))
+func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string, domain string) {
+	conn.log("InsertGlobals: [", time.String(), "] site: ", site, ", domain: ", domain, ", nodes: ", stats.Nodes, ", offline: ", stats.Offline, ", new: ", stats.New, ", clients: ", stats.Clients, " models: ", len(stats.Models), ", firmwares: ", len(stats.Firmwares))
+}
+
+func (conn *Connection) InsertCollectorStats(stats runtime.CollectorStats, time time.Time) {
+	for iface, s := range stats {
+		conn.log("InsertCollectorStats: [", time.String(), "] interface: ", iface, ", received: ", s.Received, ", parse errors: ", s.ParseErrors, ", truncated: ", s.Truncated, ", queue overflow: ", s.QueueOverflow)
+	}
 }
 
 func (conn *Connection) PruneNodes(deleteAfter time.Duration) {
@@ -70,6 +79,6 @@ func (conn *Connection) Close() {
 }
 
 func (conn *Connection) log(v ...interface{}) {
-	log.Println(v)
+	log.Println(v...)
 	conn.file.WriteString(fmt.Sprintln("[", time.Now().String(), "]", v))
 }
@@ -0,0 +1,397 @@
+// Package expression implements a small filter language evaluated against nodes, e.g.
+//
+//	site_code in ["ffhb", "ffhb-nord"] && online && model !~ "^TP-Link TL-WR841"
+//
+// Expressions are compiled once, type errors and invalid regular expressions
+// are reported by Compile.
+package expression
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindString
+	kindNumber
+	kindList
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "bool"
+	case kindString:
+		return "string"
+	case kindNumber:
+		return "number"
+	}
+	return "list"
+}
+
+// Error is a syntax or type error at the offset of the source
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", err.Pos+1, err.Msg)
+}
+
+// Expression is a compiled expression
+type Expression struct {
+	source string
+	match  func(*runtime.Node) bool
+}
+
+// Compile parses the source and returns the expression
+func Compile(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t.text)}
+	}
+	if root.kind != kindBool {
+		return nil, &Error{Pos: 0, Msg: fmt.Sprintf("expression is a %s, expected bool", root.kind)}
+	}
+	return &Expression{source: source, match: root.bool}, nil
+}
+
+// Match returns whether the node matches the expression
+func (e *Expression) Match(node *runtime.Node) bool {
+	return e.match(node)
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// operand is a compiled part of an expression, the function of its kind is set
+type operand struct {
+	kind   kind
+	pos    int
+	bool   func(*runtime.Node) bool
+	string func(*runtime.Node) string
+	number func(*runtime.Node) float64
+
+	constant bool   // literal value, required for regular expressions and lists
+	text     string // the value of a string literal
+	list     []operand
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given operator or keyword
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); (t.typ == tokenOperator || t.typ == tokenIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (*operand, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := expectBool(t, left, right); err != nil {
+			return nil, err
+		}
+		a, b := left.bool, right.bool
+		left = &operand{kind: kindBool, pos: left.pos, bool: func(node *runtime.Node) bool {
+			return a(node) || b(node)
+		}}
+	}
+}
+
+func (p *parser) parseAnd() (*operand, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("&&") {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if err := expectBool(t, left, right); err != nil {
+			return nil, err
+		}
+		a, b := left.bool, right.bool
+		left = &operand{kind: kindBool, pos: left.pos, bool: func(node *runtime.Node) bool {
+			return a(node) && b(node)
+		}}
+	}
+}
+
+func (p *parser) parseNot() (*operand, error) {
+	t := p.peek()
+	if !p.accept("!") {
+		return p.parseComparison()
+	}
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if err := expectBool(t, operand); err != nil {
+		return nil, err
+	}
+	f := operand.bool
+	operand.bool = func(node *runtime.Node) bool {
+		return !f(node)
+	}
+	operand.pos = t.pos
+	return operand, nil
+}
+
+func expectBool(t token, operands ...*operand) error {
+	for _, o := range operands {
+		if o.kind != kindBool {
+			return &Error{Pos: t.pos, Msg: fmt.Sprintf("%s expects bool operands, got %s", t.text, o.kind)}
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseComparison() (*operand, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	op := ""
+	switch {
+	case t.typ == tokenOperator && t.text != "&&" && t.text != "||" && t.text != "!":
+		op = t.text
+		p.next()
+	case t.typ == tokenIdent && t.text == "in":
+		op = "in"
+		p.next()
+	case t.typ == tokenIdent && t.text == "not":
+		p.next()
+		if !p.accept("in") {
+			return nil, &Error{Pos: t.pos, Msg: "expected in after not"}
+		}
+		op = "not in"
+	default:
+		return left, nil
+	}
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	result, err := compare(op, left, right)
+	if err != nil {
+		return nil, &Error{Pos: t.pos, Msg: err.Error()}
+	}
+	result.pos = left.pos
+	return result, nil
+}
+
+func (p *parser) parsePrimary() (*operand, error) {
+	t := p.next()
+	switch t.typ {
+	case tokenLParen:
+		operand, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.typ != tokenRParen {
+			return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("expected ), got %s", closing.text)}
+		}
+		return operand, nil
+	case tokenLBracket:
+		list := &operand{kind: kindList, pos: t.pos, constant: true}
+		for p.peek().typ != tokenRBracket {
+			item, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			if !item.constant || (item.kind != kindString && item.kind != kindNumber) {
+				return nil, &Error{Pos: item.pos, Msg: "lists may only contain strings and numbers"}
+			}
+			list.list = append(list.list, *item)
+			if p.peek().typ == tokenComma {
+				p.next()
+			} else if p.peek().typ != tokenRBracket {
+				return nil, &Error{Pos: p.peek().pos, Msg: fmt.Sprintf("expected , or ], got %s", p.peek().text)}
+			}
+		}
+		p.next()
+		return list, nil
+	case tokenString:
+		text := t.text
+		return &operand{kind: kindString, pos: t.pos, constant: true, text: text, string: func(*runtime.Node) string {
+			return text
+		}}, nil
+	case tokenNumber:
+		value := t.value
+		return &operand{kind: kindNumber, pos: t.pos, constant: true, number: func(*runtime.Node) float64 {
+			return value
+		}}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			value := t.text == "true"
+			return &operand{kind: kindBool, pos: t.pos, constant: true, bool: func(*runtime.Node) bool {
+				return value
+			}}, nil
+		}
+		f, ok := lookupField(t.text)
+		if !ok {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown field %s", t.text)}
+		}
+		return &operand{kind: f.kind, pos: t.pos, bool: f.bool, string: f.string, number: f.number}, nil
+	}
+	return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t.text)}
+}
+
+// compare returns the boolean operand comparing both operands
+func compare(op string, left, right *operand) (*operand, error) {
+	result := &operand{kind: kindBool}
+
+	switch op {
+	case "=~", "!~":
+		if left.kind != kindString || right.kind != kindString || !right.constant {
+			return nil, fmt.Errorf("%s expects a string and a regular expression", op)
+		}
+		re, err := regexp.Compile(right.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %s", err)
+		}
+		value, negate := left.string, op == "!~"
+		result.bool = func(node *runtime.Node) bool {
+			return re.MatchString(value(node)) != negate
+		}
+		return result, nil
+
+	case "in", "not in":
+		if right.kind != kindList {
+			return nil, fmt.Errorf("%s expects a list, got %s", op, right.kind)
+		}
+		negate := op == "not in"
+		switch left.kind {
+		case kindString:
+			set := make(map[string]bool)
+			for _, item := range right.list {
+				if item.kind != kindString {
+					return nil, fmt.Errorf("%s expects a list of strings", op)
+				}
+				set[item.text] = true
+			}
+			value := left.string
+			result.bool = func(node *runtime.Node) bool {
+				return set[value(node)] != negate
+			}
+		case kindNumber:
+			set := make(map[float64]bool)
+			for _, item := range right.list {
+				if item.kind != kindNumber {
+					return nil, fmt.Errorf("%s expects a list of numbers", op)
+				}
+				set[item.number(nil)] = true
+			}
+			value := left.number
+			result.bool = func(node *runtime.Node) bool {
+				return set[value(node)] != negate
+			}
+		default:
+			return nil, fmt.Errorf("%s expects a string or number, got %s", op, left.kind)
+		}
+		return result, nil
+	}
+
+	if left.kind != right.kind {
+		return nil, fmt.Errorf("can not compare %s with %s", left.kind, right.kind)
+	}
+	switch left.kind {
+	case kindBool:
+		a, b := left.bool, right.bool
+		switch op {
+		case "==":
+			result.bool = func(node *runtime.Node) bool { return a(node) == b(node) }
+		case "!=":
+			result.bool = func(node *runtime.Node) bool { return a(node) != b(node) }
+		default:
+			return nil, fmt.Errorf("%s is not defined for bool", op)
+		}
+	case kindString:
+		a, b := left.string, right.string
+		switch op {
+		case "==":
+			result.bool = func(node *runtime.Node) bool { return a(node) == b(node) }
+		case "!=":
+			result.bool = func(node *runtime.Node) bool { return a(node) != b(node) }
+		case "<":
+			result.bool = func(node *runtime.Node) bool { return a(node) < b(node) }
+		case "<=":
+			result.bool = func(node *runtime.Node) bool { return a(node) <= b(node) }
+		case ">":
+			result.bool = func(node *runtime.Node) bool { return a(node) > b(node) }
+		case ">=":
+			result.bool = func(node *runtime.Node) bool { return a(node) >= b(node) }
+		}
+	case kindNumber:
+		a, b := left.number, right.number
+		switch op {
+		case "==":
+			result.bool = func(node *runtime.Node) bool { return a(node) == b(node) }
+		case "!=":
+			result.bool = func(node *runtime.Node) bool { return a(node) != b(node) }
+		case "<":
+			result.bool = func(node *runtime.Node) bool { return a(node) < b(node) }
+		case "<=":
+			result.bool = func(node *runtime.Node) bool { return a(node) <= b(node) }
+		case ">":
+			result.bool = func(node *runtime.Node) bool { return a(node) > b(node) }
+		case ">=":
+			result.bool = func(node *runtime.Node) bool { return a(node) >= b(node) }
+		}
+	default:
+		return nil, fmt.Errorf("%s is not defined for lists", op)
+	}
+	return result, nil
+}
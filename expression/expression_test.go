@@ -0,0 +1,126 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func testNode() *runtime.Node {
+	return &runtime.Node{
+		Online:   true,
+		Lastseen: jsontime.Now().Add(-time.Minute),
+		Nodeinfo: &data.NodeInfo{
+			NodeID:   "00112233445566",
+			Hostname: "ffhb-node",
+			System:   data.System{SiteCode: "ffhb-nord"},
+			Hardware: data.Hardware{Model: "TP-Link TL-WR841N/ND v9", Nproc: 1},
+			Location: &data.Location{Latitude: 53.07, Longitude: 8.8},
+		},
+		Statistics: &data.Statistics{
+			Clients: data.Clients{Total: 12},
+		},
+		Tags: map[string]string{"room": "kitchen"},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	assert := assert.New(t)
+	node := testNode()
+
+	for source, expected := range map[string]bool{
+		`online`:                                     true,
+		`!online`:                                    false,
+		`!!online`:                                   true,
+		`gateway`:                                    false,
+		`online == true`:                             true,
+		`site_code == "ffhb-nord"`:                   true,
+		`site_code != 'ffhb-nord'`:                   false,
+		`site_code in ["ffhb", "ffhb-nord"]`:         true,
+		`site_code not in ["ffhb", "ffhb-nord"]`:     false,
+		`site_code in []`:                            false,
+		`model =~ "^TP-Link TL-WR841"`:               true,
+		`model !~ "^TP-Link TL-WR841"`:               false,
+		`clients > 10 && clients <= 12`:              true,
+		`clients < 10 || clients >= 20`:              false,
+		`nproc in [1, 2]`:                            true,
+		`latitude > 53.0 && longitude < 9`:           true,
+		`has_location && !vpn`:                       true,
+		`tags.room == "kitchen"`:                     true,
+		`tags.floor == ""`:                           true,
+		`domain_code == ""`:                          true,
+		`owner == ""`:                                true,
+		`lastseen_ago < 120 && lastseen_ago >= 60`:   true,
+		`!(online && gateway) && (true || false)`:    true,
+		`online && gateway || clients == 12`:         true,
+		`online && (gateway || clients == 11)`:       false,
+		`hostname > "ffhb-a" && hostname < "ffhb-z"`: true,
+		`autoupdater == "disabled"`:                  true,
+		`site_code in ["ffhb","ffhb-nord"] && online && model !~ "^TP-Link TL-WR841"`: false,
+	} {
+		e, err := Compile(source)
+		if assert.NoError(err, source) {
+			assert.Equal(expected, e.Match(node), source)
+			assert.Equal(source, e.String())
+		}
+	}
+}
+
+func TestMatchMissingData(t *testing.T) {
+	assert := assert.New(t)
+	node := &runtime.Node{}
+
+	for source, expected := range map[string]bool{
+		`site_code == ""`: true,
+		`has_location`:    false,
+		`clients == 0`:    true,
+		`node_id == ""`:   true,
+		`tags.room == ""`: true,
+		`vpn`:             false,
+	} {
+		e, err := Compile(source)
+		if assert.NoError(err, source) {
+			assert.Equal(expected, e.Match(node), source)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	for source, msg := range map[string]string{
+		``:                        "position 1: unexpected end of expression",
+		`site_code`:               "position 1: expression is a string, expected bool",
+		`foo`:                     "position 1: unknown field foo",
+		`online &&`:               "position 10: unexpected end of expression",
+		`online && clients`:       "position 8: && expects bool operands, got number",
+		`!clients`:                "position 1: ! expects bool operands, got number",
+		`clients == "12"`:         "position 9: can not compare number with string",
+		`online < true`:           "position 8: < is not defined for bool",
+		`model =~ "("`:            "position 7: invalid regular expression: error parsing regexp: missing closing ): `(`",
+		`model =~ hostname`:       "position 7: =~ expects a string and a regular expression",
+		`site_code in "ffhb"`:     "position 11: in expects a list, got string",
+		`site_code in [1]`:        "position 11: in expects a list of strings",
+		`site_code in [hostname]`: "position 15: lists may only contain strings and numbers",
+		`site_code in ["a" "b"]`:  "position 19: expected , or ], got b",
+		`online in [true]`:        "position 12: lists may only contain strings and numbers",
+		`site_code not ["a"]`:     "position 11: expected in after not",
+		`(online`:                 "position 8: expected ), got end of expression",
+		`online)`:                 "position 7: unexpected )",
+		`online == true == true`:  "position 16: unexpected ==",
+		`site_code == "ffhb`:      "position 14: unterminated string",
+		`clients > 1.2.3`:         "position 11: invalid number 1.2.3",
+		`online & gateway`:        "position 8: unexpected character '&'",
+		`[1] == [1]`:              "position 5: == is not defined for lists",
+	} {
+		_, err := Compile(source)
+		if assert.Error(err, source) {
+			assert.Equal(msg, err.Error(), source)
+		}
+	}
+}
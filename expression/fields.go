@@ -0,0 +1,196 @@
+package expression
+
+import (
+	"strings"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// field is a value of a node, missing data results in the zero value
+type field struct {
+	kind   kind
+	bool   func(*runtime.Node) bool
+	string func(*runtime.Node) string
+	number func(*runtime.Node) float64
+}
+
+func stringField(f func(*runtime.Node) string) field {
+	return field{kind: kindString, string: f}
+}
+
+func numberField(f func(*runtime.Node) float64) field {
+	return field{kind: kindNumber, number: f}
+}
+
+func boolField(f func(*runtime.Node) bool) field {
+	return field{kind: kindBool, bool: f}
+}
+
+// tagPrefix is the prefix of the fields of the tags of the overlay, e.g. tags.room
+const tagPrefix = "tags."
+
+var fields = map[string]field{
+	// Node ID
+	"node_id": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.NodeID
+		}
+		if stats := node.Statistics; stats != nil {
+			return stats.NodeID
+		}
+		return ""
+	}),
+	// Hostname
+	"hostname": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.Hostname
+		}
+		return ""
+	}),
+	// Primary MAC address
+	"mac": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.Network.Mac
+		}
+		return ""
+	}),
+	// Site code
+	"site_code": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.System.SiteCode
+		}
+		return ""
+	}),
+	// Domain code (Gluon multidomain)
+	"domain_code": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.System.DomainCode
+		}
+		return ""
+	}),
+	// Hardware model
+	"model": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.Hardware.Model
+		}
+		return ""
+	}),
+	// Number of processors
+	"nproc": numberField(func(node *runtime.Node) float64 {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return float64(nodeinfo.Hardware.Nproc)
+		}
+		return 0
+	}),
+	// Firmware base, e.g. gluon-v2016.2.7
+	"firmware_base": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.Software.Firmware.Base
+		}
+		return ""
+	}),
+	// Firmware release
+	"firmware_release": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			return nodeinfo.Software.Firmware.Release
+		}
+		return ""
+	}),
+	// Autoupdater branch or "disabled"
+	"autoupdater": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			if nodeinfo.Software.Autoupdater.Enabled {
+				return nodeinfo.Software.Autoupdater.Branch
+			}
+			return runtime.DISABLED_AUTOUPDATER
+		}
+		return ""
+	}),
+	// Contact of the owner
+	"owner": stringField(func(node *runtime.Node) string {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil && nodeinfo.Owner != nil {
+			return nodeinfo.Owner.Contact
+		}
+		return ""
+	}),
+	// Whether the node has geo-coordinates
+	"has_location": boolField(func(node *runtime.Node) bool {
+		return node.Nodeinfo != nil && node.Nodeinfo.Location != nil
+	}),
+	// Latitude
+	"latitude": numberField(func(node *runtime.Node) float64 {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil && nodeinfo.Location != nil {
+			return nodeinfo.Location.Latitude
+		}
+		return 0
+	}),
+	// Longitude
+	"longitude": numberField(func(node *runtime.Node) float64 {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil && nodeinfo.Location != nil {
+			return nodeinfo.Location.Longitude
+		}
+		return 0
+	}),
+	// Whether the node is online
+	"online": boolField(func(node *runtime.Node) bool {
+		return node.Online
+	}),
+	// Whether the node is a gateway
+	"gateway": boolField(func(node *runtime.Node) bool {
+		return node.IsGateway()
+	}),
+	// Whether the node has an established mesh VPN connection
+	"vpn": boolField(func(node *runtime.Node) bool {
+		return len(node.MeshVPNPeers()) > 0
+	}),
+	// Whether the node is in maintenance
+	"maintenance": boolField(func(node *runtime.Node) bool {
+		return node.Maintenance
+	}),
+	// Whether the node conflicts with another node
+	"conflict": boolField(func(node *runtime.Node) bool {
+		return node.Conflict
+	}),
+	// Number of clients
+	"clients": numberField(func(node *runtime.Node) float64 {
+		if stats := node.Statistics; stats != nil {
+			return float64(stats.Clients.Total)
+		}
+		return 0
+	}),
+	// Uptime in seconds
+	"uptime": numberField(func(node *runtime.Node) float64 {
+		if stats := node.Statistics; stats != nil {
+			return stats.Uptime
+		}
+		return 0
+	}),
+	// Load average
+	"load": numberField(func(node *runtime.Node) float64 {
+		if stats := node.Statistics; stats != nil {
+			return stats.LoadAverage
+		}
+		return 0
+	}),
+	// Seconds since the node was seen last
+	"lastseen_ago": numberField(func(node *runtime.Node) float64 {
+		return time.Since(node.Lastseen.GetTime()).Seconds()
+	}),
+	// Seconds since the node was seen first
+	"firstseen_ago": numberField(func(node *runtime.Node) float64 {
+		return time.Since(node.Firstseen.GetTime()).Seconds()
+	}),
+}
+
+// lookupField returns the field with the name, including the tags of the overlay
+func lookupField(name string) (field, bool) {
+	if strings.HasPrefix(name, tagPrefix) && len(name) > len(tagPrefix) {
+		tag := name[len(tagPrefix):]
+		return stringField(func(node *runtime.Node) string {
+			return node.Tags[tag]
+		}), true
+	}
+	f, ok := fields[name]
+	return f, ok
+}
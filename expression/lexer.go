@@ -0,0 +1,120 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator // e.g. && or =~
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+type token struct {
+	typ   tokenType
+	text  string // the source of the token, the unquoted value of a string
+	pos   int    // offset in the source
+	value float64
+}
+
+// operators ordered by length, to match the longest first
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!"}
+
+// lex splits the source into tokens
+func lex(source string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for pos < len(source) {
+		c := rune(source[pos])
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+		case c == '(':
+			tokens = append(tokens, token{typ: tokenLParen, text: "(", pos: pos})
+			pos++
+		case c == ')':
+			tokens = append(tokens, token{typ: tokenRParen, text: ")", pos: pos})
+			pos++
+		case c == '[':
+			tokens = append(tokens, token{typ: tokenLBracket, text: "[", pos: pos})
+			pos++
+		case c == ']':
+			tokens = append(tokens, token{typ: tokenRBracket, text: "]", pos: pos})
+			pos++
+		case c == ',':
+			tokens = append(tokens, token{typ: tokenComma, text: ",", pos: pos})
+			pos++
+		case c == '"' || c == '\'':
+			end := pos + 1
+			for end < len(source) && source[end] != byte(c) {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, &Error{Pos: pos, Msg: "unterminated string"}
+			}
+			quoted := source[pos : end+1]
+			if c == '\'' {
+				// single quotes are allowed to avoid escaping in TOML
+				quoted = `"` + strings.Replace(quoted[1:len(quoted)-1], `"`, `\"`, -1) + `"`
+			}
+			text, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, &Error{Pos: pos, Msg: "invalid string " + source[pos:end+1]}
+			}
+			tokens = append(tokens, token{typ: tokenString, text: text, pos: pos})
+			pos = end + 1
+		case unicode.IsDigit(c) || c == '-' || c == '.':
+			end := pos + 1
+			for end < len(source) && (unicode.IsDigit(rune(source[end])) || source[end] == '.') {
+				end++
+			}
+			value, err := strconv.ParseFloat(source[pos:end], 64)
+			if err != nil {
+				return nil, &Error{Pos: pos, Msg: "invalid number " + source[pos:end]}
+			}
+			tokens = append(tokens, token{typ: tokenNumber, text: source[pos:end], pos: pos, value: value})
+			pos = end
+		case unicode.IsLetter(c) || c == '_':
+			end := pos + 1
+			for end < len(source) && isIdentChar(rune(source[end])) {
+				end++
+			}
+			tokens = append(tokens, token{typ: tokenIdent, text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(source[pos:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			tokens = append(tokens, token{typ: tokenOperator, text: op, pos: pos})
+			pos += len(op)
+		}
+	}
+	return append(tokens, token{typ: tokenEOF, text: "end of expression", pos: pos}), nil
+}
+
+// isIdentChar returns whether the character is allowed in a field name, e.g. tags.room-1
+func isIdentChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' || c == '-'
+}
@@ -0,0 +1,113 @@
+package all
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// FilterOptions are the options of [nodes.output.<type>.filter]
+var FilterOptions = runtime.Options{
+	{Name: "no_owner", Type: runtime.OptionBool, Default: true, Doc: "Remove the owner information"},
+	{Name: "blacklist", Type: runtime.OptionList, Doc: "Node IDs of nodes, which are filtered out"},
+	{Name: "domains", Type: runtime.OptionList, Doc: "Include only nodes of these domain codes (Gluon multidomain)"},
+	{Name: "has_location", Type: runtime.OptionBool, Doc: "Include only nodes with geo-coordinates (true) or only without (false)"},
+	{Name: "expression", Type: runtime.OptionString, Doc: "Include only nodes matching the expression, e.g. site_code in [\"ffhb\"] && online"},
+	{Name: "in_area", Type: runtime.OptionTable, Doc: "Remove the nodes outside this area, see [nodes.output.<type>.filter.in_area]"},
+	{Name: "in_polygon", Type: runtime.OptionString, Doc: "Remove the nodes outside the polygons of this GeoJSON file"},
+	{Name: "location_privacy", Type: runtime.OptionTable, Doc: "Hide the exact location of the nodes, see [nodes.output.<type>.filter.location_privacy]"},
+}
+
+// AreaOptions are the options of [nodes.output.<type>.filter.in_area]
+var AreaOptions = runtime.Options{
+	{Name: "latitude_min", Type: runtime.OptionFloat, Required: true},
+	{Name: "latitude_max", Type: runtime.OptionFloat, Required: true},
+	{Name: "longitude_min", Type: runtime.OptionFloat, Required: true},
+	{Name: "longitude_max", Type: runtime.OptionFloat, Required: true},
+}
+
+// LocationPrivacyOptions are the options of [nodes.output.<type>.filter.location_privacy]
+var LocationPrivacyOptions = runtime.Options{
+	{Name: "jitter", Type: runtime.OptionFloat, Doc: "Move the location up to this distance in meters, the offset is fixed per node"},
+	{Name: "seed", Type: runtime.OptionString, Doc: "Secret of the jitter, without it the offset could be calculated from the node ID"},
+	{Name: "grid", Type: runtime.OptionFloat, Doc: "Snap the location to the center of a grid cell of this size in meters"},
+	{Name: "decimals", Type: runtime.OptionInt, Doc: "Round the coordinates to this number of decimals, e.g. 3 is about 100 meters"},
+}
+
+// Check validates the configuration of all outputs and returns every problem
+func Check(configuration map[string]interface{}) (errs []error) {
+	types := make([]string, 0, len(configuration))
+	for outputType := range configuration {
+		types = append(types, outputType)
+	}
+	sort.Strings(types)
+
+	for _, outputType := range types {
+		prefix := "nodes.output." + outputType
+		if _, ok := output.Adapters[outputType]; !ok {
+			errs = append(errs, &runtime.OptionError{Key: prefix, Msg: "unknown output"})
+			continue
+		}
+		outputConfigs, ok := configuration[outputType].([]map[string]interface{})
+		if !ok {
+			errs = append(errs, &runtime.OptionError{Key: prefix, Msg: fmt.Sprintf("expected [[%s]]", prefix)})
+			continue
+		}
+		for i, config := range outputConfigs {
+			errs = append(errs, validate(outputType, i, config)...)
+		}
+	}
+	return
+}
+
+// validate checks the configuration of an output, sets its defaults and those of its filter
+func validate(outputType string, i int, config map[string]interface{}) []error {
+	options, ok := output.Options[outputType]
+	if !ok {
+		return nil
+	}
+	prefix := fmt.Sprintf("nodes.output.%s[%d]", outputType, i)
+	errs := options.Validate(prefix, config)
+	if upload, ok := config["upload"].(map[string]interface{}); ok {
+		uploadErrs := output.UploadOptions.Validate(prefix+".upload", upload)
+		errs = append(errs, uploadErrs...)
+		if len(uploadErrs) == 0 {
+			if _, err := output.NewPublisher(config); err != nil {
+				errs = append(errs, &runtime.OptionError{Key: prefix + ".upload", Msg: err.Error()})
+			}
+		}
+	}
+
+	if list, ok := config["transform"].([]map[string]interface{}); ok {
+		for i, transform := range list {
+			errs = append(errs, TransformOptions.Validate(fmt.Sprintf("%s.transform[%d]", prefix, i), transform)...)
+		}
+		if _, err := newTransforms(list); err != nil {
+			errs = append(errs, &runtime.OptionError{Key: prefix, Msg: err.Error()})
+		}
+	}
+
+	filter, ok := config["filter"].(map[string]interface{})
+	if !ok {
+		return errs
+	}
+	errs = append(errs, FilterOptions.Validate(prefix+".filter", filter)...)
+	if area, ok := filter["in_area"].(map[string]interface{}); ok {
+		errs = append(errs, AreaOptions.Validate(prefix+".filter.in_area", area)...)
+	}
+	if privacy, ok := filter["location_privacy"].(map[string]interface{}); ok {
+		errs = append(errs, LocationPrivacyOptions.Validate(prefix+".filter.location_privacy", privacy)...)
+		if jitter, _ := privacy["jitter"].(float64); jitter > 0 && privacy["seed"] == nil {
+			errs = append(errs, &runtime.OptionError{Key: prefix + ".filter.location_privacy.seed", Msg: "required by jitter"})
+		}
+	}
+	if _, err := filterConfig(filter).InPolygon(); err != nil {
+		errs = append(errs, &runtime.OptionError{Key: prefix + ".filter.in_polygon", Msg: err.Error()})
+	}
+	if _, err := filterConfig(filter).Expression(); err != nil {
+		errs = append(errs, &runtime.OptionError{Key: prefix + ".filter.expression", Msg: err.Error()})
+	}
+	return errs
+}
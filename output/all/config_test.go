@@ -0,0 +1,64 @@
+package all
+
+import (
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	output.RegisterAdapter("checked", func(config map[string]interface{}) (output.Output, error) {
+		return &testOutput{}, nil
+	}, runtime.Option{Name: "path", Type: runtime.OptionString, Required: true})
+
+	config := map[string]interface{}{
+		"checked": []map[string]interface{}{
+			{"path": "a"},
+			{"enable": "yes", "filter": map[string]interface{}{
+				"no_owner": false,
+				"in_area":  map[string]interface{}{"latitude_min": int64(34)},
+			}},
+			{"path": "b", "transform": []map[string]interface{}{
+				{"type": "strip_addresses"},
+				{"type": "rename", "pattern": "^ffhb-"},
+			}, "filter": map[string]interface{}{
+				"expression":       "online &&",
+				"in_polygon":       "testdata/missing.geojson",
+				"location_privacy": map[string]interface{}{"jitter": 100.0},
+			}},
+		},
+		"unknown": []map[string]interface{}{{}},
+	}
+	var msgs []string
+	for _, err := range Check(config) {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal([]string{
+		"nodes.output.checked[1].enable: expected bool, got \"yes\"",
+		"nodes.output.checked[1].path: missing required option",
+		"nodes.output.checked[1].filter.in_area.latitude_min: expected float, got 34",
+		"nodes.output.checked[1].filter.in_area.latitude_max: missing required option",
+		"nodes.output.checked[1].filter.in_area.longitude_min: missing required option",
+		"nodes.output.checked[1].filter.in_area.longitude_max: missing required option",
+		"nodes.output.checked[2]: transform[1]: unknown type \"rename\"",
+		"nodes.output.checked[2].filter.location_privacy.seed: required by jitter",
+		"nodes.output.checked[2].filter.in_polygon: open testdata/missing.geojson: no such file or directory",
+		"nodes.output.checked[2].filter.expression: position 10: unexpected end of expression",
+		"nodes.output.unknown: unknown output",
+	}, msgs)
+
+	// defaults are set
+	first := config["checked"].([]map[string]interface{})[0]
+	assert.Equal(true, first["enable"])
+
+	_, err := Register(map[string]interface{}{
+		"checked": []map[string]interface{}{{}},
+	})
+	assert.EqualError(err, "nodes.output.checked[0].path: missing required option")
+	delete(output.Adapters, "checked")
+	delete(output.Options, "checked")
+}
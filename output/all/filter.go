@@ -11,22 +11,42 @@ func noFilter(node *runtime.Node) *runtime.Node {
 	return node
 }
 
-// Create Filter
-func (f filterConfig) filtering(nodesOrigin *runtime.Nodes) *runtime.Nodes {
-	nodes := runtime.NewNodes(&runtime.Config{})
-	filterfuncs := []filterFunc{
+type filterList []filterFunc
+
+// filters creates the filters of the configuration, this is done once per output
+func (f filterConfig) filters() (filterList, error) {
+	inPolygon, err := f.InPolygon()
+	if err != nil {
+		return nil, err
+	}
+	expression, err := f.Expression()
+	if err != nil {
+		return nil, err
+	}
+	// the location is changed after all filters, which depend on it
+	return filterList{
 		f.HasLocation(),
 		f.Blacklist(),
+		f.Domains(),
 		f.InArea(),
+		inPolygon,
+		expression,
+		f.LocationPrivacy(),
 		f.NoOwner(),
-	}
+	}, nil
+}
+
+// Create Filter
+func (filterfuncs filterList) filtering(nodesOrigin *runtime.Nodes) *runtime.Nodes {
+	nodes := runtime.NewNodes(&runtime.Config{})
 
-	nodesOrigin.Lock()
-	defer nodesOrigin.Unlock()
+	nodesOrigin.RLock()
+	defer nodesOrigin.RUnlock()
 
 	for _, nodeOrigin := range nodesOrigin.List {
-		//maybe cloning of this object is better?
-		node := nodeOrigin
+		// each output works on its own copy of the node, but the data of the node is shared
+		// with the other outputs: it has to be copied before changing it, see editNodeinfo
+		node := nodeOrigin.Copy()
 		for _, f := range filterfuncs {
 			node = f(node)
 			if node == nil {
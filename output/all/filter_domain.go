@@ -0,0 +1,25 @@
+package all
+
+import "github.com/FreifunkBremen/yanic/runtime"
+
+// Domains keeps only the nodes of the given domain codes (Gluon multidomain)
+func (f filterConfig) Domains() filterFunc {
+	v, ok := f["domains"]
+	if !ok {
+		return noFilter
+	}
+
+	list := make(map[string]interface{})
+	for _, domain := range v.([]interface{}) {
+		list[domain.(string)] = true
+	}
+
+	return func(node *runtime.Node) *runtime.Node {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			if _, ok := list[nodeinfo.System.DomainCode]; ok {
+				return node
+			}
+		}
+		return nil
+	}
+}
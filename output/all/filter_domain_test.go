@@ -0,0 +1,36 @@
+package all
+
+import (
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterDomains(t *testing.T) {
+	assert := assert.New(t)
+	var config filterConfig
+
+	config = map[string]interface{}{}
+
+	filterDomains := config.Domains()
+
+	n := filterDomains(&runtime.Node{})
+	assert.NotNil(n)
+
+	config["domains"] = []interface{}{"ffhb_nord", "ffhb_sued"}
+	filterDomains = config.Domains()
+
+	n = filterDomains(&runtime.Node{Nodeinfo: &data.NodeInfo{System: data.System{DomainCode: "ffhb_sued"}}})
+	assert.NotNil(n)
+
+	n = filterDomains(&runtime.Node{Nodeinfo: &data.NodeInfo{System: data.System{DomainCode: "ffhb_west"}}})
+	assert.Nil(n)
+
+	n = filterDomains(&runtime.Node{Nodeinfo: &data.NodeInfo{}})
+	assert.Nil(n)
+
+	n = filterDomains(&runtime.Node{})
+	assert.Nil(n)
+}
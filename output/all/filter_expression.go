@@ -0,0 +1,25 @@
+package all
+
+import (
+	"github.com/FreifunkBremen/yanic/expression"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Expression keeps only the nodes matching the expression, see package expression
+func (f filterConfig) Expression() (filterFunc, error) {
+	source, ok := f["expression"].(string)
+	if !ok || source == "" {
+		return noFilter, nil
+	}
+	e, err := expression.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(node *runtime.Node) *runtime.Node {
+		if e.Match(node) {
+			return node
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,39 @@
+package all
+
+import (
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterExpression(t *testing.T) {
+	assert := assert.New(t)
+	var config filterConfig
+
+	config = map[string]interface{}{}
+
+	filterExpression, err := config.Expression()
+	assert.NoError(err)
+
+	n := filterExpression(&runtime.Node{})
+	assert.NotNil(n)
+
+	config["expression"] = `site_code in ["ffhb", "ffhb-nord"] && online`
+	filterExpression, err = config.Expression()
+	assert.NoError(err)
+
+	n = filterExpression(&runtime.Node{Online: true, Nodeinfo: &data.NodeInfo{System: data.System{SiteCode: "ffhb"}}})
+	assert.NotNil(n)
+
+	n = filterExpression(&runtime.Node{Nodeinfo: &data.NodeInfo{System: data.System{SiteCode: "ffhb"}}})
+	assert.Nil(n)
+
+	n = filterExpression(&runtime.Node{Online: true})
+	assert.Nil(n)
+
+	config["expression"] = `site_code ==`
+	_, err = config.Expression()
+	assert.EqualError(err, "position 13: unexpected end of expression")
+}
@@ -0,0 +1,123 @@
+package all
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// polygon is a list of rings of [longitude, latitude], the first ring is the exterior and the others are holes
+type polygon [][][2]float64
+
+// geoJSON is a GeoJSON object, either a geometry, a feature or a collection of them
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []*geoJSON      `json:"geometries"`
+	Features    []*geoJSON      `json:"features"`
+}
+
+// polygons returns all polygons of the object, other geometries are ignored
+func (object *geoJSON) polygons() ([]polygon, error) {
+	var result []polygon
+	switch object.Type {
+	case "Polygon":
+		var p polygon
+		if err := json.Unmarshal(object.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("invalid polygon: %s", err)
+		}
+		result = append(result, p)
+	case "MultiPolygon":
+		var list []polygon
+		if err := json.Unmarshal(object.Coordinates, &list); err != nil {
+			return nil, fmt.Errorf("invalid multipolygon: %s", err)
+		}
+		result = append(result, list...)
+	case "Feature":
+		if object.Geometry != nil {
+			return object.Geometry.polygons()
+		}
+	case "FeatureCollection", "GeometryCollection":
+		for _, child := range append(object.Features, object.Geometries...) {
+			polygons, err := child.polygons()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, polygons...)
+		}
+	}
+	return result, nil
+}
+
+// loadPolygons reads the polygons of a GeoJSON file
+func loadPolygons(path string) ([]polygon, error) {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	object := &geoJSON{}
+	if err = json.Unmarshal(file, object); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	polygons, err := object.polygons()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("%s: no polygons found", path)
+	}
+	return polygons, nil
+}
+
+// contains returns whether the point is inside the exterior ring and outside of all holes
+func (p polygon) contains(longitude, latitude float64) bool {
+	for i, ring := range p {
+		if insideRing(ring, longitude, latitude) != (i == 0) {
+			return false
+		}
+	}
+	return len(p) > 0
+}
+
+// insideRing implements the ray casting algorithm
+func insideRing(ring [][2]float64, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// InPolygon removes the nodes outside the polygons of a GeoJSON file, e.g. the boundary of a city
+func (f filterConfig) InPolygon() (filterFunc, error) {
+	path, ok := f["in_polygon"].(string)
+	if !ok || path == "" {
+		return noFilter, nil
+	}
+	polygons, err := loadPolygons(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(node *runtime.Node) *runtime.Node {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			location := nodeinfo.Location
+			if location == nil {
+				return node
+			}
+			for _, p := range polygons {
+				if p.contains(location.Longitude, location.Latitude) {
+					return node
+				}
+			}
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,75 @@
+package all
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterInPolygon(t *testing.T) {
+	assert := assert.New(t)
+	var config filterConfig
+
+	config = map[string]interface{}{}
+	filter, err := config.InPolygon()
+	assert.NoError(err)
+	n := filter(&runtime.Node{})
+	assert.NotNil(n)
+
+	config["in_polygon"] = "testdata/area.geojson"
+	filter, err = config.InPolygon()
+	assert.NoError(err)
+
+	// drop without nodeinfo
+	n = filter(&runtime.Node{})
+	assert.Nil(n)
+
+	// keep without location
+	n = filter(&runtime.Node{Nodeinfo: &data.NodeInfo{}})
+	assert.NotNil(n)
+
+	for _, location := range []data.Location{
+		{Latitude: 4.5, Longitude: 11.5},  // polygon
+		{Latitude: 1.0, Longitude: 21.0},  // first of the multipolygon
+		{Latitude: -4.0, Longitude: -4.0}, // second of the multipolygon
+	} {
+		l := location
+		n = filter(&runtime.Node{Nodeinfo: &data.NodeInfo{Location: &l}})
+		assert.NotNil(n, "%v", location)
+	}
+
+	for _, location := range []data.Location{
+		{},                                 // the point is no area
+		{Latitude: 3.75, Longitude: 10.75}, // in the hole
+		{Latitude: 6.0, Longitude: 11.0},   // above
+		{Latitude: 1.9, Longitude: 20.2},   // beside the triangle
+	} {
+		l := location
+		n = filter(&runtime.Node{Nodeinfo: &data.NodeInfo{Location: &l}})
+		assert.Nil(n, "%v", location)
+	}
+}
+
+func TestFilterInPolygonErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := filterConfig{"in_polygon": "testdata/missing.geojson"}.InPolygon()
+	assert.Error(err)
+
+	file, err := ioutil.TempFile("", "yanic-polygon")
+	assert.NoError(err)
+	defer os.Remove(file.Name())
+
+	file.WriteString(`{"type": "Point", "coordinates": [1, 2]}`)
+	file.Close()
+	_, err = filterConfig{"in_polygon": file.Name()}.InPolygon()
+	assert.EqualError(err, file.Name()+": no polygons found")
+
+	ioutil.WriteFile(file.Name(), []byte(`{"type": "Polygon", "coordinates": [1, 2]}`), 0644)
+	_, err = filterConfig{"in_polygon": file.Name()}.InPolygon()
+	assert.Contains(err.Error(), file.Name()+": invalid polygon: ")
+}
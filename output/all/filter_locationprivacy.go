@@ -0,0 +1,82 @@
+package all
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// metersPerDegree of latitude
+const metersPerDegree = 111320.0
+
+// LocationPrivacy hides the exact location of the nodes:
+// it is moved by a fixed offset per node (jitter), snapped to the center of a grid cell
+// and rounded to a number of decimals, in this order.
+func (f filterConfig) LocationPrivacy() filterFunc {
+	config, ok := f["location_privacy"].(map[string]interface{})
+	if !ok {
+		return noFilter
+	}
+	jitter, _ := config["jitter"].(float64)
+	seed, _ := config["seed"].(string)
+	grid, _ := config["grid"].(float64)
+	decimals, round := config["decimals"].(int64)
+
+	hide := func(nodeID string, location data.Location) *data.Location {
+		if jitter > 0 {
+			distance, angle := jitterOffset(seed, nodeID)
+			distance *= jitter
+			location.Latitude += distance * math.Cos(angle) / metersPerDegree
+			location.Longitude += distance * math.Sin(angle) / (metersPerDegree * math.Cos(location.Latitude*math.Pi/180))
+		}
+		if grid > 0 {
+			cell := grid / metersPerDegree
+			location.Latitude = (math.Floor(location.Latitude/cell) + 0.5) * cell
+			cell = grid / (metersPerDegree * math.Cos(location.Latitude*math.Pi/180))
+			location.Longitude = (math.Floor(location.Longitude/cell) + 0.5) * cell
+		}
+		if round {
+			factor := math.Pow(10, float64(decimals))
+			location.Latitude = math.Floor(location.Latitude*factor+0.5) / factor
+			location.Longitude = math.Floor(location.Longitude*factor+0.5) / factor
+		}
+		return &location
+	}
+
+	return func(node *runtime.Node) *runtime.Node {
+		nodeinfo := node.Nodeinfo
+		overrides := node.Overrides
+		if (nodeinfo == nil || nodeinfo.Location == nil) && (overrides == nil || overrides.Location == nil) {
+			return node
+		}
+
+		c := node.Copy()
+		if nodeinfo != nil && nodeinfo.Location != nil {
+			info := *nodeinfo
+			info.Location = hide(nodeinfo.NodeID, *nodeinfo.Location)
+			c.Nodeinfo = &info
+		}
+		// the overridden location is part of the raw nodes as well
+		if overrides != nil && overrides.Location != nil {
+			c.Overrides = overrides.Copy()
+			if nodeinfo != nil {
+				c.Overrides.Location = hide(nodeinfo.NodeID, *overrides.Location)
+			} else {
+				c.Overrides.Location = nil
+			}
+		}
+		return c
+	}
+}
+
+// jitterOffset returns a distance factor and an angle, which are always the same for a node.
+// The distance is distributed uniformly over the area of the circle.
+func jitterOffset(seed, nodeID string) (distance, angle float64) {
+	sum := sha256.Sum256([]byte(seed + "\x00" + nodeID))
+	u1 := float64(binary.BigEndian.Uint64(sum[0:8])) / math.MaxUint64
+	u2 := float64(binary.BigEndian.Uint64(sum[8:16])) / math.MaxUint64
+	return math.Sqrt(u1), 2 * math.Pi * u2
+}
@@ -0,0 +1,97 @@
+package all
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+// distance in meters, good enough for small distances
+func distance(a, b *data.Location) float64 {
+	dLat := (a.Latitude - b.Latitude) * metersPerDegree
+	dLon := (a.Longitude - b.Longitude) * metersPerDegree * math.Cos(a.Latitude*math.Pi/180)
+	return math.Sqrt(dLat*dLat + dLon*dLon)
+}
+
+func privacyNode(nodeID string) *runtime.Node {
+	return &runtime.Node{Nodeinfo: &data.NodeInfo{
+		NodeID:   nodeID,
+		Location: &data.Location{Latitude: 53.0793, Longitude: 8.8017},
+	}}
+}
+
+func TestFilterLocationPrivacy(t *testing.T) {
+	assert := assert.New(t)
+	var config filterConfig
+
+	config = map[string]interface{}{}
+	filter := config.LocationPrivacy()
+	node := privacyNode("a")
+	assert.True(node == filter(node))
+
+	// without location
+	config["location_privacy"] = map[string]interface{}{"decimals": int64(2)}
+	filter = config.LocationPrivacy()
+	node = &runtime.Node{Nodeinfo: &data.NodeInfo{}}
+	assert.True(node == filter(node))
+
+	// rounded, the original node is unchanged
+	node = privacyNode("a")
+	n := filter(node)
+	assert.Equal(53.08, n.Nodeinfo.Location.Latitude)
+	assert.Equal(8.8, n.Nodeinfo.Location.Longitude)
+	assert.Equal(53.0793, node.Nodeinfo.Location.Latitude)
+
+	// all nodes of the cell are at its center
+	config["location_privacy"] = map[string]interface{}{"grid": 500.0}
+	filter = config.LocationPrivacy()
+	a := filter(privacyNode("a")).Nodeinfo.Location
+	node = privacyNode("b")
+	node.Nodeinfo.Location.Latitude += 0.0001
+	node.Nodeinfo.Location.Longitude -= 0.0001
+	b := filter(node).Nodeinfo.Location
+	assert.Equal(a, b)
+	assert.True(distance(a, privacyNode("a").Nodeinfo.Location) < 500)
+
+	// the offset is fixed per node and seed
+	config["location_privacy"] = map[string]interface{}{"jitter": 200.0, "seed": "secret"}
+	filter = config.LocationPrivacy()
+	a = filter(privacyNode("a")).Nodeinfo.Location
+	assert.Equal(a, filter(privacyNode("a")).Nodeinfo.Location)
+	b = filter(privacyNode("b")).Nodeinfo.Location
+	assert.NotEqual(a, b)
+	original := privacyNode("a").Nodeinfo.Location
+	assert.True(distance(a, original) <= 200)
+	assert.True(distance(b, original) <= 200)
+
+	config["location_privacy"] = map[string]interface{}{"jitter": 200.0, "seed": "other"}
+	filter = config.LocationPrivacy()
+	assert.NotEqual(a, filter(privacyNode("a")).Nodeinfo.Location)
+}
+
+func TestFilterLocationPrivacyOverrides(t *testing.T) {
+	assert := assert.New(t)
+	var config filterConfig = map[string]interface{}{
+		"location_privacy": map[string]interface{}{"decimals": int64(2)},
+	}
+	filter := config.LocationPrivacy()
+
+	// the overridden location is applied to the nodeinfo of the snapshot and kept in the overrides
+	node := privacyNode("a")
+	node.Overrides = &runtime.NodeOverrides{Location: &data.Location{Latitude: 53.0793, Longitude: 8.8017}}
+	orphan := &runtime.Node{Overrides: &runtime.NodeOverrides{Location: &data.Location{Latitude: 53.0793, Longitude: 8.8017}}}
+
+	nodes := &runtime.Nodes{List: map[string]*runtime.Node{"a": filter(node), "b": filter(orphan)}}
+	raw, err := json.Marshal(nodes)
+	assert.NoError(err)
+	assert.Contains(string(raw), `"overrides"`)
+	assert.NotContains(string(raw), "53.0793")
+	assert.NotContains(string(raw), "8.8017")
+	assert.Equal(53.08, nodes.List["a"].Overrides.Location.Latitude)
+	assert.Nil(nodes.List["b"].Overrides.Location)
+	assert.Equal(53.0793, node.Overrides.Location.Latitude)
+}
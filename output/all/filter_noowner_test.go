@@ -26,6 +26,12 @@ func TestFilterNoOwner(t *testing.T) {
 	n = filterNoOwner(&runtime.Node{})
 	assert.NotNil(n)
 
+	// without nodeinfo
+	node := &runtime.Node{Overrides: &runtime.NodeOverrides{Owner: "blub", Note: "new antenna"}}
+	n = filterNoOwner(node)
+	assert.Equal(&runtime.NodeOverrides{Note: "new antenna"}, n.Overrides)
+	assert.Equal("blub", node.Overrides.Owner)
+
 	config["no_owner"] = true
 	filterNoOwner = config.NoOwner()
 	n = filterNoOwner(&runtime.Node{Nodeinfo: &data.NodeInfo{
@@ -47,3 +53,29 @@ func TestFilterNoOwner(t *testing.T) {
 	assert.NotNil(n)
 	assert.NotNil(n.Nodeinfo.Owner)
 }
+
+func TestFilterNoOwnerKeepsNode(t *testing.T) {
+	assert := assert.New(t)
+
+	filterNoOwner := filterConfig{}.NoOwner()
+	node := &runtime.Node{
+		Maintenance: true,
+		Tags:        map[string]string{"rack": "a"},
+		Overrides:   &runtime.NodeOverrides{Owner: "blub", Note: "new antenna"},
+		Nodeinfo: &data.NodeInfo{
+			Hostname: "node",
+			Owner:    &data.Owner{Contact: "blub"},
+		},
+	}
+	n := filterNoOwner(node)
+	assert.Nil(n.Nodeinfo.Owner)
+	assert.Equal("node", n.Nodeinfo.Hostname)
+	assert.True(n.Maintenance)
+	assert.Equal("a", n.Tags["rack"])
+	assert.Equal("", n.Overrides.Owner)
+	assert.Equal("new antenna", n.Overrides.Note)
+
+	// the original node is not modified
+	assert.NotNil(node.Nodeinfo.Owner)
+	assert.Equal("blub", node.Overrides.Owner)
+}
@@ -22,7 +22,9 @@ func TestFilter(t *testing.T) {
 	config := filterConfig{
 		"has_location": true,
 	}
-	nodes = config.filtering(nodes)
+	filters, err := config.filters()
+	assert.NoError(err)
+	nodes = filters.filtering(nodes)
 	assert.Len(nodes.List, 0)
 
 	// run to end
@@ -36,6 +38,8 @@ func TestFilter(t *testing.T) {
 	config = filterConfig{
 		"has_location": false,
 	}
-	nodes = config.filtering(nodes)
+	filters, err = config.filters()
+	assert.NoError(err)
+	nodes = filters.filtering(nodes)
 	assert.Len(nodes.List, 1)
 }
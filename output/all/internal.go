@@ -1,7 +1,10 @@
 package all
 
 import (
+	"fmt"
 	"log"
+	"reflect"
+	"sync"
 
 	"github.com/FreifunkBremen/yanic/output"
 	"github.com/FreifunkBremen/yanic/runtime"
@@ -9,14 +12,56 @@ import (
 
 type Output struct {
 	output.Output
-	list   map[int]output.Output
-	filter map[int]filterConfig
+	entries []*entry
+	saving  sync.WaitGroup // saves started by SaveDue
+	sync.Mutex
+}
+
+// entry is a registered output with the configuration it was created from
+type entry struct {
+	key      string // type and index, e.g. meshviewer[0]
+	config   map[string]interface{}
+	output   output.Output
+	filter   filterConfig
+	filters  filterList // created from the filter and transform configuration
+	schedule schedule
+	state    *state
 }
 
 func Register(configuration map[string]interface{}) (output.Output, error) {
-	list := make(map[int]output.Output)
-	filter := make(map[int]filterConfig)
-	i := 1
+	entries, err := register(configuration, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{entries: entries}, nil
+}
+
+// Reload applies a changed configuration, only changed outputs are created again.
+// The filters are always replaced. On error the previous outputs are kept.
+func (o *Output) Reload(configuration map[string]interface{}) error {
+	o.Lock()
+	old := o.entries
+	o.Unlock()
+
+	entries, err := register(configuration, old)
+	if err != nil {
+		return err
+	}
+
+	o.Lock()
+	o.entries = entries
+	o.Unlock()
+	return nil
+}
+
+// register creates the outputs of the configuration, unchanged ones are taken from the given entries
+func register(configuration map[string]interface{}, old []*entry) ([]*entry, error) {
+	previous := make(map[string]*entry, len(old))
+	for _, e := range old {
+		previous[e.key] = e
+	}
+
+	var entries []*entry
 	allOutputs := configuration
 	for outputType, outputRegister := range output.Adapters {
 		configForOutput := allOutputs[outputType]
@@ -28,36 +73,91 @@ func Register(configuration map[string]interface{}) (output.Output, error) {
 		if !ok {
 			log.Panicf("the output type '%s' has the wrong format\n", outputType)
 		}
-		for _, config := range outputConfigs {
+		for n, config := range outputConfigs {
 			if c, ok := config["enable"].(bool); ok && !c {
 				continue
 			}
-			output, err := outputRegister(config)
+			if errs := validate(outputType, n, config); len(errs) > 0 {
+				return nil, errs[0]
+			}
+			e := &entry{
+				key:    fmt.Sprintf("%s[%d]", outputType, n),
+				config: config,
+			}
+			if c := config["filter"]; c != nil {
+				e.filter = c.(map[string]interface{})
+			}
+			filters, err := e.filter.filters()
 			if err != nil {
 				return nil, err
 			}
-			if output == nil {
-				continue
+			transforms, _ := config["transform"].([]map[string]interface{})
+			list, err := newTransforms(transforms)
+			if err != nil {
+				return nil, err
 			}
-			list[i] = output
-			if c := config["filter"]; c != nil {
-				filter[i] = config["filter"].(map[string]interface{})
+			// the transformations run after the filters
+			e.filters = append(filters, list...)
+			e.schedule = newSchedule(config)
+			e.state = &state{}
+			if p := previous[e.key]; p != nil {
+				if reflect.DeepEqual(p.config, config) {
+					e.state = p.state
+				} else {
+					p.state.Lock()
+					e.state.stats = p.state.stats
+					p.state.Unlock()
+				}
+			}
+
+			if p := previous[e.key]; p != nil && sameOutput(p.config, config) {
+				e.output = p.output
+			} else {
+				if old != nil {
+					log.Printf("reload: creating output %s", e.key)
+				}
+				output, err := outputRegister(config)
+				if err != nil {
+					return nil, err
+				}
+				if output == nil {
+					continue
+				}
+				e.output = output
 			}
-			i++
+			entries = append(entries, e)
 		}
 	}
-	return &Output{list: list, filter: filter}, nil
+	return entries, nil
 }
 
-func (o *Output) Save(nodes *runtime.Nodes) {
-	for i, item := range o.list {
-		var filteredNodes *runtime.Nodes
-		if config := o.filter[i]; config != nil {
-			filteredNodes = config.filtering(nodes)
-		} else {
-			filteredNodes = filterConfig{}.filtering(nodes)
+// sameOutput returns true if the configurations only differ in the filter, the transformations and the schedule
+func sameOutput(a, b map[string]interface{}) bool {
+	for _, config := range []map[string]interface{}{a, b} {
+		for key := range config {
+			if key != "filter" && key != "transform" && !scheduleOptions[key] && !reflect.DeepEqual(a[key], b[key]) {
+				return false
+			}
 		}
+	}
+	return true
+}
 
-		item.Save(filteredNodes)
+// Save writes all outputs and returns the first error
+func (o *Output) Save(nodes *runtime.Nodes) (err error) {
+	// all outputs share one snapshot to not block the collector while serializing
+	nodes = nodes.Snapshot()
+	// an output is not written twice at once
+	o.Wait()
+
+	o.Lock()
+	entries := o.entries
+	o.Unlock()
+
+	for _, e := range entries {
+		if saveErr := e.save(e.filters.filtering(nodes)); err == nil {
+			err = saveErr
+		}
 	}
+	return
 }
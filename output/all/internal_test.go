@@ -16,10 +16,11 @@ type testOutput struct {
 	sync.Mutex
 }
 
-func (c *testOutput) Save(nodes *runtime.Nodes) {
+func (c *testOutput) Save(nodes *runtime.Nodes) error {
 	c.Lock()
 	c.countSave++
 	c.Unlock()
+	return nil
 }
 func (c *testOutput) Get() int {
 	c.Lock()
@@ -96,3 +97,46 @@ func TestStart(t *testing.T) {
 		})
 	})
 }
+
+func TestReload(t *testing.T) {
+	assert := assert.New(t)
+
+	created := 0
+	output.RegisterAdapter("reload", func(config map[string]interface{}) (output.Output, error) {
+		created++
+		return &testOutput{}, nil
+	})
+	defer delete(output.Adapters, "reload")
+
+	configuration := func(path string, filter map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"reload": []map[string]interface{}{
+				{"path": "a"},
+				{"path": path, "filter": filter},
+			},
+		}
+	}
+
+	o, err := Register(configuration("b", nil))
+	assert.NoError(err)
+	assert.Equal(2, created)
+	all := o.(*Output)
+	first, second := all.entries[0].output, all.entries[1].output
+
+	// only the filter changed
+	assert.NoError(all.Reload(configuration("b", map[string]interface{}{"no_owner": false})))
+	assert.Equal(2, created)
+	assert.True(second == all.entries[1].output)
+	assert.Equal(false, all.entries[1].filter["no_owner"])
+
+	// the path of the second output changed
+	assert.NoError(all.Reload(configuration("c", nil)))
+	assert.Equal(3, created)
+	assert.True(first == all.entries[0].output)
+	assert.True(second != all.entries[1].output)
+	assert.Nil(all.entries[1].filter)
+
+	// removed
+	assert.NoError(all.Reload(map[string]interface{}{}))
+	assert.Len(all.entries, 0)
+}
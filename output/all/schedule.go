@@ -0,0 +1,219 @@
+package all
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// schedule of an output
+type schedule struct {
+	interval time.Duration // 0 for nodes.save_interval
+	onChange bool
+	debounce time.Duration
+}
+
+// scheduleOptions are the options of an output, which do not require to create it again on a change
+var scheduleOptions = map[string]bool{"save_interval": true, "on_change": true, "debounce": true}
+
+func newSchedule(config map[string]interface{}) schedule {
+	s := schedule{
+		interval: durationOption(config["save_interval"]),
+		debounce: durationOption(config["debounce"]),
+	}
+	s.onChange, _ = config["on_change"].(bool)
+	return s
+}
+
+// durationOption returns the duration of an option, which is validated already
+func durationOption(value interface{}) time.Duration {
+	if value == nil {
+		return 0
+	}
+	var duration runtime.Duration
+	duration.UnmarshalTOML(value)
+	return duration.Duration
+}
+
+// state of an output, which is kept on reload
+type state struct {
+	// only used by SaveDue
+	lastSave    time.Time
+	version     uint64            // version of the nodes checked last
+	fingerprint [sha256.Size]byte // of the nodes written last
+	changed     time.Time         // first change, which is not written yet
+
+	saving bool // a save started by SaveDue is running
+	stats  output.Stats
+	sync.Mutex
+}
+
+// SaveDue writes the outputs, which are due at the given time:
+// either their interval passed or a node of the output changed (on_change).
+// The outputs are written in the background, a slow one (e.g. on upload) does not delay the others.
+// An output is not due until its previous save finished.
+func (o *Output) SaveDue(nodes *runtime.Nodes, now time.Time, saveInterval time.Duration) {
+	o.Lock()
+	entries := o.entries
+	o.Unlock()
+
+	version := nodes.Version()
+	var snapshot *runtime.Nodes
+	getSnapshot := func() *runtime.Nodes {
+		if snapshot == nil {
+			snapshot = nodes.Snapshot()
+		}
+		return snapshot
+	}
+
+	for _, e := range entries {
+		s := e.state
+		if s.isSaving() {
+			continue
+		}
+		if !e.schedule.onChange {
+			interval := e.schedule.interval
+			if interval <= 0 {
+				interval = saveInterval
+			}
+			if now.Sub(s.lastSave) >= interval {
+				s.lastSave = now
+				o.saveBackground(e, e.filters.filtering(getSnapshot()))
+			}
+			continue
+		}
+
+		if s.changed.IsZero() {
+			if version == s.version {
+				continue
+			}
+			s.version = version
+			if fingerprint(e.filters.filtering(getSnapshot())) == s.fingerprint {
+				continue
+			}
+			s.changed = now
+		}
+		if now.Sub(s.changed) < e.schedule.debounce {
+			continue
+		}
+		// the nodes may have changed again during the debounce
+		filtered := e.filters.filtering(getSnapshot())
+		s.version = version
+		s.fingerprint = fingerprint(filtered)
+		s.changed = time.Time{}
+		s.lastSave = now
+		o.saveBackground(e, filtered)
+	}
+}
+
+// saveBackground writes the output in its own goroutine
+func (o *Output) saveBackground(e *entry, nodes *runtime.Nodes) {
+	e.state.Lock()
+	e.state.saving = true
+	e.state.Unlock()
+
+	o.saving.Add(1)
+	go func() {
+		defer o.saving.Done()
+		e.save(nodes)
+
+		e.state.Lock()
+		e.state.saving = false
+		e.state.Unlock()
+	}()
+}
+
+// Wait waits for the outputs written in the background by SaveDue
+func (o *Output) Wait() {
+	o.saving.Wait()
+}
+
+func (s *state) isSaving() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.saving
+}
+
+// save writes the output and records the duration and the error
+func (e *entry) save(nodes *runtime.Nodes) error {
+	start := time.Now()
+	err := e.write(nodes)
+	duration := time.Since(start)
+
+	e.state.Lock()
+	e.state.stats.Add(start, duration, err)
+	e.state.Unlock()
+	if err != nil {
+		err = fmt.Errorf("output %s: %s", e.key, err)
+		log.Println(err)
+	}
+	return err
+}
+
+// write saves the nodes, a panic of the output is returned as error
+func (e *entry) write(nodes *runtime.Nodes) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return e.output.Save(nodes)
+}
+
+// fingerprint returns a hash of the nodes to detect changes.
+// Volatile data like lastseen, statistics and the quality of links is left out,
+// otherwise every response would be a change.
+func fingerprint(nodes *runtime.Nodes) [sha256.Size]byte {
+	nodes.RLock()
+	defer nodes.RUnlock()
+
+	ids := make([]string, 0, len(nodes.List))
+	for id := range nodes.List {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	for _, id := range ids {
+		node := nodes.List[id]
+		var links []string
+		for _, link := range nodes.NodeLinks(node) {
+			links = append(links, link.SourceMAC+"-"+link.TargetMAC)
+		}
+		sort.Strings(links)
+		encoder.Encode(struct {
+			ID          string
+			Online      bool
+			Nodeinfo    interface{}
+			Overrides   interface{}
+			Maintenance bool
+			Links       []string
+		}{id, node.Online, node.Nodeinfo, node.Overrides, node.Maintenance, links})
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], hash.Sum(nil))
+	return sum
+}
+
+// Stats returns the self-metrics of the outputs by type and index, e.g. meshviewer[0]
+func (o *Output) Stats() map[string]output.Stats {
+	o.Lock()
+	entries := o.entries
+	o.Unlock()
+
+	stats := make(map[string]output.Stats, len(entries))
+	for _, e := range entries {
+		e.state.Lock()
+		stats[e.key] = e.state.stats
+		e.state.Unlock()
+	}
+	return stats
+}
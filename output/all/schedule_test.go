@@ -0,0 +1,237 @@
+package all
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingOutput struct {
+	output.Output
+	panics bool
+}
+
+func (o *failingOutput) Save(nodes *runtime.Nodes) error {
+	if o.panics {
+		panic("invalid data")
+	}
+	return errors.New("disk full")
+}
+
+func TestSaveDueInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	outputs := []*testOutput{}
+	output.RegisterAdapter("scheduled", func(config map[string]interface{}) (output.Output, error) {
+		o := &testOutput{}
+		outputs = append(outputs, o)
+		return o, nil
+	})
+	defer delete(output.Adapters, "scheduled")
+
+	o, err := Register(map[string]interface{}{
+		"scheduled": []map[string]interface{}{
+			{},
+			{"save_interval": "5s"},
+		},
+	})
+	assert.NoError(err)
+	all := o.(*Output)
+	nodes := runtime.NewNodes(&runtime.Config{})
+
+	start := time.Now()
+	all.SaveDue(nodes, start, time.Second)
+	all.Wait()
+	assert.Equal(1, outputs[0].Get())
+	assert.Equal(1, outputs[1].Get())
+
+	all.SaveDue(nodes, start.Add(time.Second), time.Second)
+	all.Wait()
+	assert.Equal(2, outputs[0].Get())
+	assert.Equal(1, outputs[1].Get())
+
+	all.SaveDue(nodes, start.Add(5*time.Second), time.Second)
+	all.Wait()
+	assert.Equal(3, outputs[0].Get())
+	assert.Equal(2, outputs[1].Get())
+
+	stats := all.Stats()
+	assert.Len(stats, 2)
+	assert.EqualValues(3, stats["scheduled[0]"].Saves)
+	assert.EqualValues(0, stats["scheduled[0]"].Errors)
+
+	// the state is kept, if only the other output changed
+	assert.NoError(all.Reload(map[string]interface{}{
+		"scheduled": []map[string]interface{}{
+			{},
+			{"save_interval": "10s"},
+		},
+	}))
+	assert.Len(outputs, 2)
+	all.SaveDue(nodes, start.Add(5*time.Second), time.Second)
+	all.Wait()
+	assert.Equal(3, outputs[0].Get())
+	assert.Equal(3, outputs[1].Get())
+	assert.EqualValues(3, all.Stats()["scheduled[1]"].Saves)
+}
+
+func TestSaveDueOnChange(t *testing.T) {
+	assert := assert.New(t)
+
+	written := &testOutput{}
+	output.RegisterAdapter("changed", func(config map[string]interface{}) (output.Output, error) {
+		return written, nil
+	})
+	defer delete(output.Adapters, "changed")
+
+	o, err := Register(map[string]interface{}{
+		"changed": []map[string]interface{}{{
+			"on_change": true,
+			"debounce":  "2s",
+			"filter":    map[string]interface{}{"blacklist": []interface{}{"000000000002"}},
+		}},
+	})
+	assert.NoError(err)
+	all := o.(*Output)
+	nodes := runtime.NewNodes(&runtime.Config{})
+	update := func(nodeID, hostname string) {
+		nodes.Update(nodeID, &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: nodeID, Hostname: hostname}})
+	}
+	update("000000000001", "a")
+
+	// written after the debounce
+	start := time.Now()
+	all.SaveDue(nodes, start, time.Minute)
+	all.Wait()
+	assert.Equal(0, written.Get())
+	all.SaveDue(nodes, start.Add(time.Second), time.Minute)
+	all.Wait()
+	assert.Equal(0, written.Get())
+	all.SaveDue(nodes, start.Add(2*time.Second), time.Minute)
+	all.Wait()
+	assert.Equal(1, written.Get())
+
+	// nothing changed
+	all.SaveDue(nodes, start.Add(time.Hour), time.Minute)
+	all.Wait()
+	assert.Equal(1, written.Get())
+
+	// only lastseen and the statistics changed
+	nodes.Update("000000000001", &data.ResponseData{Statistics: &data.Statistics{NodeID: "000000000001", Clients: data.Clients{Total: 3}}})
+	all.SaveDue(nodes, start.Add(90*time.Minute), time.Minute)
+	all.Wait()
+	all.SaveDue(nodes, start.Add(100*time.Minute), time.Minute)
+	all.Wait()
+	assert.Equal(1, written.Get())
+
+	// only a node, which is filtered out, changed
+	update("000000000002", "b")
+	all.SaveDue(nodes, start.Add(2*time.Hour), time.Minute)
+	all.Wait()
+	all.SaveDue(nodes, start.Add(3*time.Hour), time.Minute)
+	all.Wait()
+	assert.Equal(1, written.Get())
+
+	update("000000000001", "c")
+	now := start.Add(4 * time.Hour)
+	all.SaveDue(nodes, now, time.Minute)
+	all.Wait()
+	assert.Equal(1, written.Get())
+	all.SaveDue(nodes, now.Add(2*time.Second), time.Minute)
+	all.Wait()
+	assert.Equal(2, written.Get())
+}
+
+type blockingOutput struct {
+	testOutput
+	release chan struct{}
+}
+
+func (o *blockingOutput) Save(nodes *runtime.Nodes) error {
+	<-o.release
+	return o.testOutput.Save(nodes)
+}
+
+func TestSaveDueBlocking(t *testing.T) {
+	assert := assert.New(t)
+
+	blocking := &blockingOutput{release: make(chan struct{})}
+	output.RegisterAdapter("blocking", func(config map[string]interface{}) (output.Output, error) {
+		return blocking, nil
+	})
+	defer delete(output.Adapters, "blocking")
+	other := &testOutput{}
+	output.RegisterAdapter("other", func(config map[string]interface{}) (output.Output, error) {
+		return other, nil
+	})
+	defer delete(output.Adapters, "other")
+
+	o, err := Register(map[string]interface{}{
+		"blocking": []map[string]interface{}{{}},
+		"other":    []map[string]interface{}{{}},
+	})
+	assert.NoError(err)
+	all := o.(*Output)
+	nodes := runtime.NewNodes(&runtime.Config{})
+
+	// the other output is written while the first one blocks
+	var otherState *state
+	for _, e := range all.entries {
+		if e.key == "other[0]" {
+			otherState = e.state
+		}
+	}
+	saved := func(count int) bool {
+		for i := 0; i < 1000 && (other.Get() < count || otherState.isSaving()); i++ {
+			time.Sleep(time.Millisecond)
+		}
+		return other.Get() == count
+	}
+	start := time.Now()
+	all.SaveDue(nodes, start, time.Second)
+	assert.True(saved(1))
+	all.SaveDue(nodes, start.Add(time.Second), time.Second)
+	assert.True(saved(2))
+
+	// the blocking output was not started again
+	close(blocking.release)
+	all.Wait()
+	assert.Equal(1, blocking.Get())
+	all.SaveDue(nodes, start.Add(2*time.Second), time.Second)
+	all.Wait()
+	assert.Equal(2, blocking.Get())
+	assert.Equal(3, other.Get())
+}
+
+func TestSaveErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	output.RegisterAdapter("failing", func(config map[string]interface{}) (output.Output, error) {
+		return &failingOutput{panics: config["panics"] == true}, nil
+	})
+	defer delete(output.Adapters, "failing")
+
+	o, err := Register(map[string]interface{}{
+		"failing": []map[string]interface{}{{}, {"panics": true}},
+	})
+	assert.NoError(err)
+	all := o.(*Output)
+	assert.EqualError(all.Save(runtime.NewNodes(&runtime.Config{})), "output failing[0]: disk full")
+	all.Save(runtime.NewNodes(&runtime.Config{}))
+
+	stats := all.Stats()["failing[0]"]
+	assert.EqualValues(2, stats.Saves)
+	assert.EqualValues(2, stats.Errors)
+	assert.Equal("disk full", stats.LastError)
+	assert.False(stats.LastSave.IsZero())
+
+	// a panic is recorded as well
+	stats = all.Stats()["failing[1]"]
+	assert.EqualValues(2, stats.Errors)
+	assert.Equal("invalid data", stats.LastError)
+}
@@ -0,0 +1,96 @@
+package all
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// TransformOptions are the options of [[nodes.output.<type>.transform]]
+var TransformOptions = runtime.Options{
+	{Name: "type", Type: runtime.OptionString, Required: true, Doc: "redact_owner, strip_addresses or rename_hostname"},
+	{Name: "pattern", Type: runtime.OptionString, Doc: "Regular expression of the hostnames to rename (rename_hostname)"},
+	{Name: "replace", Type: runtime.OptionString, Doc: "Replacement of the pattern, e.g. \"node-$1\" (rename_hostname)"},
+}
+
+// transforms creates a transformation of the nodes by its type
+var transforms = map[string]func(config map[string]interface{}) (filterFunc, error){
+	"redact_owner":    redactOwner,
+	"strip_addresses": stripAddresses,
+	"rename_hostname": renameHostname,
+}
+
+// newTransforms creates the transformations of an output in the configured order
+func newTransforms(configs []map[string]interface{}) (filterList, error) {
+	var list filterList
+	for i, config := range configs {
+		transformType, _ := config["type"].(string)
+		create, ok := transforms[transformType]
+		if !ok {
+			return nil, fmt.Errorf("transform[%d]: unknown type %q", i, transformType)
+		}
+		f, err := create(config)
+		if err != nil {
+			return nil, fmt.Errorf("transform[%d]: %s", i, err)
+		}
+		list = append(list, f)
+	}
+	return list, nil
+}
+
+// editNodeinfo replaces the nodeinfo of the node by a copy, which may be changed.
+// The node is already a copy of this output (see filtering), but its data is shared.
+func editNodeinfo(node *runtime.Node) *data.NodeInfo {
+	info := *node.Nodeinfo
+	node.Nodeinfo = &info
+	return &info
+}
+
+// editOverrides replaces the overrides of the node by a copy like editNodeinfo
+func editOverrides(node *runtime.Node) *runtime.NodeOverrides {
+	node.Overrides = node.Overrides.Copy()
+	return node.Overrides
+}
+
+// redactOwner removes the owner information like the filter no_owner
+func redactOwner(config map[string]interface{}) (filterFunc, error) {
+	return filterConfig{}.NoOwner(), nil
+}
+
+// stripAddresses removes the IP addresses of the nodes
+func stripAddresses(config map[string]interface{}) (filterFunc, error) {
+	return func(node *runtime.Node) *runtime.Node {
+		if node.Nodeinfo != nil {
+			editNodeinfo(node).Network.Addresses = nil
+		}
+		return node
+	}, nil
+}
+
+// renameHostname replaces the pattern in the hostnames of the nodes
+func renameHostname(config map[string]interface{}) (filterFunc, error) {
+	pattern, _ := config["pattern"].(string)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required by rename_hostname")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %s", err)
+	}
+	replace, _ := config["replace"].(string)
+
+	return func(node *runtime.Node) *runtime.Node {
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil && re.MatchString(nodeinfo.Hostname) {
+			info := editNodeinfo(node)
+			info.Hostname = re.ReplaceAllString(info.Hostname, replace)
+		}
+		// the overridden hostname is written as well, e.g. by the raw output
+		if overrides := node.Overrides; overrides != nil && overrides.Hostname != "" && re.MatchString(overrides.Hostname) {
+			o := editOverrides(node)
+			o.Hostname = re.ReplaceAllString(o.Hostname, replace)
+		}
+		return node
+	}, nil
+}
@@ -0,0 +1,75 @@
+package all
+
+import (
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	assert := assert.New(t)
+
+	origin := &runtime.Nodes{
+		List: map[string]*runtime.Node{
+			"a": &runtime.Node{
+				Nodeinfo: &data.NodeInfo{
+					NodeID:   "a",
+					Hostname: "ffhb-kitchen",
+					Owner:    &data.Owner{Contact: "mail@example.org"},
+					Network:  data.Network{Mac: "00:11:22:33:44:55", Addresses: []string{"fe80::1"}},
+				},
+				Overrides: &runtime.NodeOverrides{Hostname: "ffhb-kitchen", Owner: "mail@example.org"},
+			},
+			"b": &runtime.Node{
+				Nodeinfo:  &data.NodeInfo{NodeID: "b", Hostname: "gateway"},
+				Overrides: &runtime.NodeOverrides{Note: "no hostname overridden"},
+			},
+		},
+	}
+
+	transforms, err := newTransforms([]map[string]interface{}{
+		{"type": "redact_owner"},
+		{"type": "strip_addresses"},
+		{"type": "rename_hostname", "pattern": "^ffhb-(.*)$", "replace": "node-$1"},
+		{"type": "rename_hostname", "pattern": "kitchen", "replace": "room"},
+		{"type": "rename_hostname", "pattern": "^(gateway)?$", "replace": "node-$1"},
+	})
+	assert.NoError(err)
+	assert.Len(transforms, 5)
+
+	nodes := transforms.filtering(origin)
+	assert.Len(nodes.List, 2)
+	nodeinfo := nodes.List["a"].Nodeinfo
+	assert.Nil(nodeinfo.Owner)
+	assert.Nil(nodeinfo.Network.Addresses)
+	assert.Equal("00:11:22:33:44:55", nodeinfo.Network.Mac)
+	assert.Equal("node-room", nodeinfo.Hostname)
+	assert.Equal("node-gateway", nodes.List["b"].Nodeinfo.Hostname)
+	assert.Equal(&runtime.NodeOverrides{Note: "no hostname overridden"}, nodes.List["b"].Overrides)
+	assert.Equal(&runtime.NodeOverrides{Hostname: "node-room"}, nodes.List["a"].Overrides)
+
+	// the nodes of the other outputs are unchanged
+	nodeinfo = origin.List["a"].Nodeinfo
+	assert.Equal("mail@example.org", nodeinfo.Owner.Contact)
+	assert.Equal([]string{"fe80::1"}, nodeinfo.Network.Addresses)
+	assert.Equal("ffhb-kitchen", nodeinfo.Hostname)
+	assert.Equal(&runtime.NodeOverrides{Hostname: "ffhb-kitchen", Owner: "mail@example.org"}, origin.List["a"].Overrides)
+}
+
+func TestTransformErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := newTransforms([]map[string]interface{}{
+		{"type": "redact_owner"},
+		{"type": "unknown"},
+	})
+	assert.EqualError(err, "transform[1]: unknown type \"unknown\"")
+
+	_, err = newTransforms([]map[string]interface{}{{"type": "rename_hostname"}})
+	assert.EqualError(err, "transform[0]: pattern is required by rename_hostname")
+
+	_, err = newTransforms([]map[string]interface{}{{"type": "rename_hostname", "pattern": "("}})
+	assert.Contains(err.Error(), "transform[0]: invalid pattern: ")
+}
@@ -0,0 +1,169 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/output"
+	meshviewerFFRGB "github.com/FreifunkBremen/yanic/output/meshviewer-ffrgb"
+	"github.com/FreifunkBremen/yanic/output/nodelist"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// waitDelay is the time to wait for the output of the command after it was killed
+const waitDelay = time.Second
+
+// formats of the nodes given to the command
+var formats = map[string]func(nodes *runtime.Nodes) interface{}{
+	"raw":              func(nodes *runtime.Nodes) interface{} { return nodes },
+	"meshviewer-ffrgb": func(nodes *runtime.Nodes) interface{} { return meshviewerFFRGB.Transform(nodes) },
+	"nodelist":         func(nodes *runtime.Nodes) interface{} { return nodelist.Transform(nodes) },
+}
+
+type Output struct {
+	output.Output
+	command   []string
+	format    func(nodes *runtime.Nodes) interface{}
+	path      string
+	timeout   time.Duration
+	publisher *output.Publisher
+}
+
+type Config map[string]interface{}
+
+func (c Config) Command() (command []string) {
+	list, _ := c["command"].([]interface{})
+	for _, item := range list {
+		if arg, ok := item.(string); ok {
+			command = append(command, arg)
+		}
+	}
+	return
+}
+
+func (c Config) Format() string {
+	if format, ok := c["format"].(string); ok && format != "" {
+		return format
+	}
+	return "raw"
+}
+
+func (c Config) Path() string {
+	path, _ := c["path"].(string)
+	return path
+}
+
+func (c Config) Timeout() time.Duration {
+	if value, ok := c["timeout"]; ok {
+		var timeout runtime.Duration
+		if err := timeout.UnmarshalTOML(value); err == nil {
+			return timeout.Duration
+		}
+	}
+	return time.Minute
+}
+
+func init() {
+	output.RegisterAdapter("exec", Register,
+		runtime.Option{Name: "command", Type: runtime.OptionList, Required: true, Doc: "Command and its arguments, which is run after each save"},
+		runtime.Option{Name: "format", Type: runtime.OptionString, Default: "raw", Doc: "Format of the nodes: raw (nodes of yanic), meshviewer-ffrgb or nodelist"},
+		runtime.Option{Name: "path", Type: runtime.OptionString, Doc: "Write the nodes to this file and pass its paths as arguments, instead of piping them to stdin"},
+		runtime.Option{Name: "timeout", Type: runtime.OptionDuration, Default: "1m", Doc: "The command is killed after this duration"},
+	)
+}
+
+func Register(configuration map[string]interface{}) (output.Output, error) {
+	var config Config
+	config = configuration
+
+	command := config.Command()
+	if len(command) == 0 {
+		return nil, errors.New("no command given")
+	}
+	format, ok := formats[config.Format()]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, expected raw, meshviewer-ffrgb or nodelist", config.Format())
+	}
+	if _, ok := configuration["upload"]; ok {
+		// the command is run with the written file
+		return nil, errors.New("upload is not supported by exec")
+	}
+	publisher, err := output.NewPublisher(configuration)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{
+		command:   command,
+		format:    format,
+		path:      config.Path(),
+		timeout:   config.Timeout(),
+		publisher: publisher,
+	}, nil
+}
+
+func (o *Output) Save(nodes *runtime.Nodes) error {
+	nodes.RLock()
+	file, err := output.JSONFile(o.path, o.format(nodes))
+	nodes.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	args := o.command[1:]
+	var stdin []byte
+	if o.path != "" {
+		if err = o.publisher.Publish(file); err != nil {
+			return err
+		}
+		args = append(append([]string{}, args...), o.publisher.Paths(o.path)...)
+	} else {
+		stdin = file.Content
+	}
+	return o.run(args, stdin)
+}
+
+// run executes the command and logs its exit status
+func (o *Output) run(args []string, stdin []byte) error {
+	var out bytes.Buffer
+	cmd := osexec.Command(o.command[0], args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	newProcessGroup(cmd)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %s", o.command[0], err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(o.timeout):
+		killProcessGroup(cmd)
+		select {
+		case <-done:
+		case <-time.After(waitDelay):
+			// a process left the group and still holds stdout, the output is given up
+		}
+		return fmt.Errorf("%s: timeout after %s", o.command[0], o.timeout)
+	}
+	if err != nil {
+		// the error is logged by the scheduler of the outputs
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			return fmt.Errorf("%s: %s: %s", o.command[0], err, msg)
+		}
+		return fmt.Errorf("%s: %s", o.command[0], err)
+	}
+	log.Printf("exec: %s finished with %s in %s", o.command[0], cmd.ProcessState, time.Since(start))
+	return nil
+}
@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func testNodes() *runtime.Nodes {
+	return &runtime.Nodes{List: map[string]*runtime.Node{
+		"a": {Online: true, Nodeinfo: &data.NodeInfo{NodeID: "a", Hostname: "node-a"}},
+	}}
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{})
+	assert.EqualError(err, "no command given")
+
+	_, err = Register(map[string]interface{}{"command": []interface{}{"cat"}, "format": "csv"})
+	assert.EqualError(err, "unknown format \"csv\", expected raw, meshviewer-ffrgb or nodelist")
+
+	_, err = Register(map[string]interface{}{
+		"command": []interface{}{"cat"},
+		"path":    "nodes.json",
+		"upload":  map[string]interface{}{"url": "https://example.org/"},
+	})
+	assert.EqualError(err, "upload is not supported by exec")
+
+	out, err := Register(map[string]interface{}{"command": []interface{}{"cat"}})
+	assert.NoError(err)
+	assert.NotNil(out)
+}
+
+func TestSaveStdin(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "yanic-exec")
+	defer os.RemoveAll(dir)
+	result := filepath.Join(dir, "result")
+
+	out, err := Register(map[string]interface{}{
+		"command": []interface{}{"sh", "-c", "cat > " + result},
+		"format":  "nodelist",
+	})
+	assert.NoError(err)
+	assert.NoError(out.Save(testNodes()))
+
+	content, err := ioutil.ReadFile(result)
+	assert.NoError(err)
+	assert.Contains(string(content), `"name":"node-a"`)
+
+	out, _ = Register(map[string]interface{}{
+		"command": []interface{}{"sh", "-c", "cat > " + result},
+	})
+	assert.NoError(out.Save(testNodes()))
+	content, _ = ioutil.ReadFile(result)
+	assert.Contains(string(content), `"nodes":{"a":`)
+}
+
+func TestSavePath(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "yanic-exec")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "meshviewer.json")
+	result := filepath.Join(dir, "result")
+
+	// the paths are appended to the arguments
+	out, err := Register(map[string]interface{}{
+		"command":  []interface{}{"sh", "-c", "echo \"$@\" > " + result, "sh"},
+		"format":   "meshviewer-ffrgb",
+		"path":     path,
+		"compress": []interface{}{"gzip"},
+	})
+	assert.NoError(err)
+	assert.NoError(out.Save(testNodes()))
+
+	content, err := ioutil.ReadFile(result)
+	assert.NoError(err)
+	assert.Equal(path+" "+path+".gz\n", string(content))
+	content, err = ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Contains(string(content), `"hostname":"node-a"`)
+}
+
+func TestSaveError(t *testing.T) {
+	assert := assert.New(t)
+
+	out, _ := Register(map[string]interface{}{
+		"command": []interface{}{"sh", "-c", "echo broken >&2; exit 3"},
+	})
+	assert.EqualError(out.Save(testNodes()), "sh: exit status 3: broken")
+
+	out, _ = Register(map[string]interface{}{
+		"command": []interface{}{"sleep", "10"},
+		"timeout": "1m",
+	})
+	out.(*Output).timeout = 10 * time.Millisecond
+	assert.EqualError(out.Save(testNodes()), "sleep: timeout after 10ms")
+
+	// the children of a shell script are killed as well
+	out, _ = Register(map[string]interface{}{
+		"command": []interface{}{"sh", "-c", "sleep 5; echo done"},
+	})
+	out.(*Output).timeout = 100 * time.Millisecond
+	start := time.Now()
+	assert.EqualError(out.Save(testNodes()), "sh: timeout after 100ms")
+	assert.True(time.Since(start) < time.Second)
+
+	out, _ = Register(map[string]interface{}{
+		"command": []interface{}{"/nonexistent/command"},
+	})
+	assert.Error(out.Save(testNodes()))
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package exec
+
+import (
+	osexec "os/exec"
+	"syscall"
+)
+
+// newProcessGroup lets the command start its own process group
+func newProcessGroup(cmd *osexec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the command and all its children, e.g. of a shell script
+func killProcessGroup(cmd *osexec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
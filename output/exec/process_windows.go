@@ -0,0 +1,10 @@
+package exec
+
+import osexec "os/exec"
+
+func newProcessGroup(cmd *osexec.Cmd) {}
+
+// killProcessGroup kills only the command, its children have to exit on their own
+func killProcessGroup(cmd *osexec.Cmd) {
+	cmd.Process.Kill()
+}
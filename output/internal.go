@@ -1,6 +1,7 @@
 package output
 
 import (
+	"log"
 	"sync"
 	"time"
 
@@ -24,14 +25,39 @@ func Close() {
 	wg.Wait()
 }
 
+// Scheduler is an output, which decides itself when to write its outputs (see output/all)
+type Scheduler interface {
+	Output
+	// SaveDue writes the outputs, which are due at the given time.
+	// The interval is used for the outputs without an own interval.
+	SaveDue(nodes *runtime.Nodes, now time.Time, saveInterval time.Duration)
+	// Wait waits for the outputs, which are still written in the background by SaveDue
+	Wait()
+}
+
+// SchedulerTick is the interval, in which a Scheduler is asked for due outputs
+var SchedulerTick = time.Second
+
 // save periodically to output
 func saveWorker(output Output, nodes *runtime.Nodes, saveInterval time.Duration) {
-	ticker := time.NewTicker(saveInterval)
+	scheduler, scheduled := output.(Scheduler)
+	tick := saveInterval
+	if scheduled && SchedulerTick < tick {
+		tick = SchedulerTick
+	}
+	ticker := time.NewTicker(tick)
 	for {
 		select {
-		case <-ticker.C:
-			output.Save(nodes)
+		case now := <-ticker.C:
+			if scheduled {
+				scheduler.SaveDue(nodes, now, saveInterval)
+			} else if err := output.Save(nodes); err != nil {
+				log.Printf("output: %s", err)
+			}
 		case <-quit:
+			if scheduled {
+				scheduler.Wait()
+			}
 			wg.Done()
 			ticker.Stop()
 			return
@@ -15,10 +15,11 @@ type testConn struct {
 	sync.Mutex
 }
 
-func (c *testConn) Save(nodes *runtime.Nodes) {
+func (c *testConn) Save(nodes *runtime.Nodes) error {
 	c.Lock()
 	c.countSave++
 	c.Unlock()
+	return nil
 }
 func (c *testConn) Get() int {
 	c.Lock()
@@ -32,12 +33,16 @@ func TestStart(t *testing.T) {
 	conn := &testConn{}
 	config := &runtime.Config{
 		Nodes: struct {
-			Enable       bool             `toml:"enable"`
-			StatePath    string           `toml:"state_path"`
-			SaveInterval runtime.Duration `toml:"save_interval"`
-			OfflineAfter runtime.Duration `toml:"offline_after"`
-			PruneAfter   runtime.Duration `toml:"prune_after"`
-			Output       map[string]interface{}
+			Enable           bool                 `toml:"enable"`
+			StatePath        string               `toml:"state_path"`
+			StateCompression string               `toml:"state_compression"`
+			StateCompaction  runtime.Duration     `toml:"state_compaction"`
+			SaveInterval     runtime.Duration     `toml:"save_interval"`
+			OfflineAfter     runtime.Duration     `toml:"offline_after"`
+			PruneAfter       runtime.Duration     `toml:"prune_after"`
+			OverlayPath      string               `toml:"overlay_path"`
+			Policies         []runtime.NodePolicy `toml:"policy"`
+			Output           map[string]interface{}
 		}{
 			SaveInterval: runtime.Duration{Duration: time.Millisecond * 10},
 		},
@@ -56,3 +61,35 @@ func TestStart(t *testing.T) {
 	assert.Equal(2, conn.Get())
 
 }
+
+type testScheduler struct {
+	testConn
+	interval time.Duration
+}
+
+func (c *testScheduler) SaveDue(nodes *runtime.Nodes, now time.Time, saveInterval time.Duration) {
+	c.Lock()
+	c.countSave++
+	c.interval = saveInterval
+	c.Unlock()
+}
+
+func (c *testScheduler) Wait() {}
+
+func TestStartScheduler(t *testing.T) {
+	assert := assert.New(t)
+
+	tick := SchedulerTick
+	SchedulerTick = time.Millisecond * 10
+	defer func() { SchedulerTick = tick }()
+
+	conn := &testScheduler{}
+	config := &runtime.Config{}
+	config.Nodes.SaveInterval = runtime.Duration{Duration: time.Hour}
+
+	Start(conn, nil, config)
+	time.Sleep(time.Millisecond * 15)
+	Close()
+	assert.Equal(1, conn.Get())
+	assert.Equal(time.Hour, conn.interval)
+}
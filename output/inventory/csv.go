@@ -0,0 +1,52 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// writeCSV returns the header and the rows as CSV
+func writeCSV(header []string, rows [][]interface{}, delimiter rune) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = delimiter
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(header))
+	for _, row := range rows {
+		for i, value := range row {
+			record[i] = formatValue(value)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// validDelimiter returns whether the delimiter is accepted by encoding/csv
+func validDelimiter(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+// formulaPrefixes start a formula in spreadsheet applications
+const formulaPrefixes = "=+-@\t\r"
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		// e.g. a hostname set by the owner of the node must not be run as formula
+		if v != "" && strings.IndexByte(formulaPrefixes, v[0]) >= 0 {
+			return "'" + v
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
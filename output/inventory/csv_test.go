@@ -0,0 +1,24 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	content, err := writeCSV([]string{"hostname", "latitude"}, [][]interface{}{
+		{"node-a", -53.07},
+		{"=1+2", nil},
+		{"+1", nil},
+		{"-1", nil},
+		{"@SUM(A1)", nil},
+		{"\tnode", nil},
+		{"\rnode", nil},
+		{"", 0.5},
+	}, ',')
+	assert.NoError(err)
+	assert.Equal("hostname,latitude\nnode-a,-53.07\n'=1+2,\n'+1,\n'-1,\n'@SUM(A1),\n'\tnode,\n\"'\rnode\",\n,0.5\n", string(content))
+}
@@ -0,0 +1,118 @@
+package inventory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// column returns the value of a node: a string, a float64 or nil if unknown
+type column func(node *runtime.Node) interface{}
+
+// columns of the inventory by name
+var columns = map[string]column{
+	"node_id":          nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.NodeID }),
+	"hostname":         nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.Hostname }),
+	"mac":              nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.Network.Mac }),
+	"model":            nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.Hardware.Model }),
+	"firmware_base":    nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.Software.Firmware.Base }),
+	"firmware_release": nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.Software.Firmware.Release }),
+	"autoupdater": nodeinfo(func(n *runtime.Node) interface{} {
+		if autoupdater := n.Nodeinfo.Software.Autoupdater; autoupdater.Enabled {
+			return autoupdater.Branch
+		}
+		return "disabled"
+	}),
+	"site_code":   nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.System.SiteCode }),
+	"domain_code": nodeinfo(func(n *runtime.Node) interface{} { return n.Nodeinfo.System.DomainCode }),
+	"owner": nodeinfo(func(n *runtime.Node) interface{} {
+		if owner := n.Nodeinfo.Owner; owner != nil {
+			return owner.Contact
+		}
+		return nil
+	}),
+	"latitude": nodeinfo(func(n *runtime.Node) interface{} {
+		if location := n.Nodeinfo.Location; location != nil {
+			return location.Latitude
+		}
+		return nil
+	}),
+	"longitude": nodeinfo(func(n *runtime.Node) interface{} {
+		if location := n.Nodeinfo.Location; location != nil {
+			return location.Longitude
+		}
+		return nil
+	}),
+	"firstseen": func(n *runtime.Node) interface{} { return timestamp(n.Firstseen.GetTime()) },
+	"lastseen":  func(n *runtime.Node) interface{} { return timestamp(n.Lastseen.GetTime()) },
+	"online": func(n *runtime.Node) interface{} {
+		if n.Online {
+			return "yes"
+		}
+		return "no"
+	},
+	"clients": statistics(func(n *runtime.Node) interface{} { return float64(n.Statistics.Clients.Total) }),
+	"uptime":  statistics(func(n *runtime.Node) interface{} { return n.Statistics.Uptime }),
+	"memory_usage": statistics(func(n *runtime.Node) interface{} {
+		// calculated like the meshviewer-ffrgb output, 1.0 is 100%
+		if memory := n.Statistics.Memory; memory.Total > 0 {
+			return 1 - (float64(memory.Free)+float64(memory.Buffers)+float64(memory.Cached))/float64(memory.Total)
+		}
+		return nil
+	}),
+	"rootfs_usage": statistics(func(n *runtime.Node) interface{} { return n.Statistics.RootFsUsage }),
+}
+
+// defaultColumns are written if no columns are configured
+var defaultColumns = []string{
+	"node_id", "hostname", "model", "firmware_release", "autoupdater", "site_code", "owner",
+	"latitude", "longitude", "firstseen", "lastseen", "clients", "uptime", "memory_usage", "rootfs_usage",
+}
+
+// nodeinfo returns nil for nodes without nodeinfo
+func nodeinfo(f column) column {
+	return func(n *runtime.Node) interface{} {
+		if n.Nodeinfo == nil {
+			return nil
+		}
+		return f(n)
+	}
+}
+
+// statistics returns nil for nodes without statistics
+func statistics(f column) column {
+	return func(n *runtime.Node) interface{} {
+		if n.Statistics == nil {
+			return nil
+		}
+		return f(n)
+	}
+}
+
+func timestamp(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// rows returns the values of the columns of the nodes sorted by their ID
+func rows(nodes *runtime.Nodes, names []string) [][]interface{} {
+	ids := make([]string, 0, len(nodes.List))
+	for id := range nodes.List {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make([][]interface{}, 0, len(ids))
+	for _, id := range ids {
+		node := nodes.List[id]
+		row := make([]interface{}, len(names))
+		for i, name := range names {
+			row[i] = columns[name](node)
+		}
+		result = append(result, row)
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestNodes() *runtime.Nodes {
+	nodeinfo := &data.NodeInfo{
+		NodeID:   "b",
+		Hostname: "node-b",
+		Owner:    &data.Owner{Contact: "admin@example.org"},
+		Location: &data.Location{Latitude: 53.07, Longitude: 8.81},
+		System:   data.System{SiteCode: "ffhb"},
+		Hardware: data.Hardware{Model: "TP-Link TL-WR841N/ND v9"},
+	}
+	nodeinfo.Software.Firmware.Release = "2019.1"
+	nodeinfo.Software.Autoupdater.Enabled = true
+	nodeinfo.Software.Autoupdater.Branch = "stable"
+	var firstseen jsontime.Time
+	firstseen.UnmarshalJSON([]byte(`"2019-01-02T03:04:05+0000"`))
+
+	return &runtime.Nodes{List: map[string]*runtime.Node{
+		"b": {
+			Online:    true,
+			Firstseen: firstseen,
+			Nodeinfo:  nodeinfo,
+			Statistics: &data.Statistics{
+				Clients:     data.Clients{Total: 12},
+				Uptime:      3600,
+				Memory:      data.Memory{Total: 100, Free: 20, Buffers: 5, Cached: 1},
+				RootFsUsage: 0.5,
+			},
+		},
+		"a": {},
+	}}
+}
+
+func TestRows(t *testing.T) {
+	assert := assert.New(t)
+
+	result := rows(createTestNodes(), defaultColumns)
+	assert.Len(result, 2)
+
+	// nodes without nodeinfo and statistics have empty values
+	assert.Equal([]interface{}{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}, result[0])
+
+	assert.Equal([]interface{}{
+		"b", "node-b", "TP-Link TL-WR841N/ND v9", "2019.1", "stable", "ffhb", "admin@example.org",
+		53.07, 8.81, "2019-01-02T03:04:05Z", nil, 12.0, 3600.0, 0.74, 0.5,
+	}, result[1])
+
+	result = rows(createTestNodes(), []string{"online", "autoupdater"})
+	assert.Equal([]interface{}{"no", nil}, result[0])
+	assert.Equal([]interface{}{"yes", "stable"}, result[1])
+}
@@ -0,0 +1,125 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type Output struct {
+	output.Output
+	path      string
+	xlsx      bool
+	columns   []string
+	delimiter rune
+	publisher *output.Publisher
+}
+
+type Config map[string]interface{}
+
+func (c Config) Path() string {
+	path, _ := c["path"].(string)
+	return path
+}
+
+// Format returns csv or xlsx, by default the extension of the path decides
+func (c Config) Format() string {
+	if format, ok := c["format"].(string); ok && format != "" {
+		return format
+	}
+	if strings.ToLower(filepath.Ext(c.Path())) == ".xlsx" {
+		return "xlsx"
+	}
+	return "csv"
+}
+
+func (c Config) Columns() (names []string) {
+	list, _ := c["columns"].([]interface{})
+	for _, item := range list {
+		if name, ok := item.(string); ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return defaultColumns
+	}
+	return
+}
+
+func (c Config) Delimiter() string {
+	if delimiter, ok := c["delimiter"].(string); ok && delimiter != "" {
+		return delimiter
+	}
+	return ","
+}
+
+func init() {
+	output.RegisterAdapter("inventory", Register,
+		runtime.Option{Name: "path", Type: runtime.OptionString, Required: true, Doc: "Path where to store the inventory, e.g. nodes.csv or nodes.xlsx"},
+		runtime.Option{Name: "format", Type: runtime.OptionString, Doc: "csv or xlsx (default: by the extension of path)"},
+		runtime.Option{Name: "columns", Type: runtime.OptionList, Doc: "Columns of the inventory in this order (default: all except mac, firmware_base, domain_code and online)"},
+		runtime.Option{Name: "delimiter", Type: runtime.OptionString, Default: ",", Doc: "Delimiter of the CSV fields"},
+	)
+}
+
+func Register(configuration map[string]interface{}) (output.Output, error) {
+	var config Config
+	config = configuration
+
+	path := config.Path()
+	if path == "" {
+		return nil, errors.New("no path given")
+	}
+	format := config.Format()
+	if format != "csv" && format != "xlsx" {
+		return nil, fmt.Errorf("unknown format %q, expected csv or xlsx", format)
+	}
+	names := config.Columns()
+	for _, name := range names {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	delimiter, size := utf8.DecodeRuneInString(config.Delimiter())
+	if size != len(config.Delimiter()) {
+		return nil, fmt.Errorf("the delimiter %q has to be a single character", config.Delimiter())
+	}
+	if !validDelimiter(delimiter) {
+		return nil, fmt.Errorf("the delimiter %q is not allowed in CSV", config.Delimiter())
+	}
+
+	publisher, err := output.NewPublisher(configuration)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{
+		path:      path,
+		xlsx:      format == "xlsx",
+		columns:   names,
+		delimiter: delimiter,
+		publisher: publisher,
+	}, nil
+}
+
+func (o *Output) Save(nodes *runtime.Nodes) error {
+	nodes.RLock()
+	rows := rows(nodes, o.columns)
+	nodes.RUnlock()
+
+	var content []byte
+	var err error
+	if o.xlsx {
+		content, err = writeXLSX(o.columns, rows)
+	} else {
+		content, err = writeCSV(o.columns, rows, o.delimiter)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", o.path, err)
+	}
+	return o.publisher.Publish(output.File{Path: o.path, Content: content})
+}
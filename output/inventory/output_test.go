@@ -0,0 +1,57 @@
+package inventory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "yanic-inventory")
+	defer os.RemoveAll(dir)
+
+	out, err := Register(map[string]interface{}{})
+	assert.EqualError(err, "no path given")
+	assert.Nil(out)
+
+	_, err = Register(map[string]interface{}{"path": "nodes.ods"})
+	assert.NoError(err)
+	_, err = Register(map[string]interface{}{"path": "nodes.ods", "format": "ods"})
+	assert.EqualError(err, "unknown format \"ods\", expected csv or xlsx")
+	_, err = Register(map[string]interface{}{"path": "nodes.csv", "columns": []interface{}{"hostname", "color"}})
+	assert.EqualError(err, "unknown column \"color\"")
+	_, err = Register(map[string]interface{}{"path": "nodes.csv", "delimiter": ";;"})
+	assert.EqualError(err, "the delimiter \";;\" has to be a single character")
+	for _, delimiter := range []string{"\"", "\n", "\r", "\x00"} {
+		_, err = Register(map[string]interface{}{"path": "nodes.csv", "delimiter": delimiter})
+		assert.EqualError(err, fmt.Sprintf("the delimiter %q is not allowed in CSV", delimiter))
+	}
+
+	path := filepath.Join(dir, "nodes.csv")
+	out, err = Register(map[string]interface{}{
+		"path":      path,
+		"columns":   []interface{}{"node_id", "hostname", "latitude", "clients"},
+		"delimiter": ";",
+	})
+	assert.NoError(err)
+	assert.NoError(out.Save(createTestNodes()))
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("node_id;hostname;latitude;clients\n;;;\nb;node-b;53.07;12\n", string(content))
+
+	// the format is taken from the extension
+	path = filepath.Join(dir, "nodes.xlsx")
+	out, err = Register(map[string]interface{}{"path": path})
+	assert.NoError(err)
+	assert.NoError(out.Save(createTestNodes()))
+	content, err = ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("PK", string(content[:2]))
+}
@@ -0,0 +1,95 @@
+package inventory
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"strconv"
+)
+
+// parts of a workbook with one worksheet, which is added as xl/worksheets/sheet1.xml
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Nodes" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// writeXLSX returns the header and the rows as workbook of Office Open XML
+func writeXLSX(header []string, rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, part := range xlsxParts {
+		f, err := w.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = f.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := w.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Write(worksheet(header, rows)); err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	return buf.Bytes(), err
+}
+
+// worksheet returns the XML of the worksheet, strings are stored inline
+func worksheet(header []string, rows [][]interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	headerRow := make([]interface{}, len(header))
+	for i, name := range header {
+		headerRow[i] = name
+	}
+	for r, row := range append([][]interface{}{headerRow}, rows...) {
+		number := strconv.Itoa(r + 1)
+		buf.WriteString(`<row r="` + number + `">`)
+		for c, value := range row {
+			ref := cellColumn(c) + number
+			switch v := value.(type) {
+			case string:
+				buf.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t>`)
+				xml.EscapeText(&buf, []byte(v))
+				buf.WriteString(`</t></is></c>`)
+			case float64:
+				buf.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(v, 'g', -1, 64) + `</v></c>`)
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.Bytes()
+}
+
+// cellColumn returns the name of the column with the index, e.g. A, Z, AA
+func cellColumn(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
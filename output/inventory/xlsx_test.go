@@ -0,0 +1,49 @@
+package inventory
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	assert := assert.New(t)
+
+	content, err := writeXLSX([]string{"hostname", "clients"}, [][]interface{}{
+		{"a & b", 12.0},
+		{nil, 0.5},
+	})
+	assert.NoError(err)
+
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	assert.NoError(err)
+	parts := make(map[string]string)
+	for _, f := range r.File {
+		rc, _ := f.Open()
+		part, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(part)
+	}
+	assert.Len(parts, 5)
+	assert.Contains(parts["[Content_Types].xml"], `PartName="/xl/worksheets/sheet1.xml"`)
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(sheet, `<row r="1"><c r="A1" t="inlineStr"><is><t>hostname</t></is></c><c r="B1" t="inlineStr"><is><t>clients</t></is></c></row>`)
+	assert.Contains(sheet, `<row r="2"><c r="A2" t="inlineStr"><is><t>a &amp; b</t></is></c><c r="B2"><v>12</v></c></row>`)
+	assert.Contains(sheet, `<row r="3"><c r="B3"><v>0.5</v></c></row>`)
+}
+
+func TestCellColumn(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("A", cellColumn(0))
+	assert.Equal("Z", cellColumn(25))
+	assert.Equal("AA", cellColumn(26))
+	assert.Equal("AZ", cellColumn(51))
+	assert.Equal("BA", cellColumn(52))
+	assert.Equal("ZZ", cellColumn(701))
+	assert.Equal("AAA", cellColumn(702))
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/FreifunkBremen/yanic/runtime"
 )
 
-func transform(nodes *runtime.Nodes) *Meshviewer {
+// Transform returns the meshviewer.json of the nodes
+func Transform(nodes *runtime.Nodes) *Meshviewer {
 
 	meshviewer := &Meshviewer{
 		Timestamp: jsontime.Now(),
@@ -37,9 +38,6 @@ func transform(nodes *runtime.Nodes) *Meshviewer {
 					for _, mac := range mesh.Interfaces.Wireless {
 						typeList[mac] = "wifi"
 					}
-					for _, mac := range mesh.Interfaces.Tunnel {
-						typeList[mac] = "vpn"
-					}
 				}
 			}
 		}
@@ -62,7 +60,9 @@ func transform(nodes *runtime.Nodes) *Meshviewer {
 				continue
 			}
 			linkType := typeList[linkOrigin.SourceMAC]
-			if linkType == "" {
+			if linkOrigin.VPN {
+				linkType = "vpn"
+			} else if linkType == "" {
 				linkType = "other"
 			}
 			tq := float32(linkOrigin.TQ) / 255.0
@@ -152,7 +152,7 @@ func TestTransform(t *testing.T) {
 		},
 	})
 
-	meshviewer := transform(nodes)
+	meshviewer := Transform(nodes)
 	assert.NotNil(meshviewer)
 	assert.Len(meshviewer.Nodes, 4)
 	links := meshviewer.Links
@@ -9,7 +9,8 @@ import (
 
 type Output struct {
 	output.Output
-	path string
+	path      string
+	publisher *output.Publisher
 }
 
 type Config map[string]interface{}
@@ -22,7 +23,9 @@ func (c Config) Path() string {
 }
 
 func init() {
-	output.RegisterAdapter("meshviewer-ffrgb", Register)
+	output.RegisterAdapter("meshviewer-ffrgb", Register,
+		runtime.Option{Name: "path", Type: runtime.OptionString, Required: true, Doc: "Path where to store meshviewer.json"},
+	)
 }
 
 func Register(configuration map[string]interface{}) (output.Output, error) {
@@ -30,14 +33,23 @@ func Register(configuration map[string]interface{}) (output.Output, error) {
 	config = configuration
 
 	if path := config.Path(); path != "" {
+		publisher, err := output.NewPublisher(configuration)
+		if err != nil {
+			return nil, err
+		}
 		return &Output{
-			path: path,
+			path:      path,
+			publisher: publisher,
 		}, nil
 	}
 	return nil, errors.New("no path given")
 
 }
 
-func (o *Output) Save(nodes *runtime.Nodes) {
-	runtime.SaveJSON(transform(nodes), o.path)
+func (o *Output) Save(nodes *runtime.Nodes) error {
+	file, err := output.JSONFile(o.path, Transform(nodes))
+	if err != nil {
+		return err
+	}
+	return o.publisher.Publish(file)
 }
@@ -21,7 +21,7 @@ func TestOutput(t *testing.T) {
 	assert.NoError(err)
 	assert.NotNil(out)
 
-	out.Save(&runtime.Nodes{})
+	assert.NoError(out.Save(&runtime.Nodes{}))
 	_, err = os.Stat("/tmp/meshviewer.json")
 	assert.NoError(err)
 }
@@ -3,6 +3,7 @@ package meshviewerFFRGB
 import (
 	"time"
 
+	"github.com/FreifunkBremen/yanic/data"
 	"github.com/FreifunkBremen/yanic/jsontime"
 	"github.com/FreifunkBremen/yanic/runtime"
 )
@@ -14,33 +15,38 @@ type Meshviewer struct {
 }
 
 type Node struct {
-	Firstseen      jsontime.Time `json:"firstseen"`
-	Lastseen       jsontime.Time `json:"lastseen"`
-	IsOnline       bool          `json:"is_online"`
-	IsGateway      bool          `json:"is_gateway"`
-	Clients        uint32        `json:"clients"`
-	ClientsWifi24  uint32        `json:"clients_wifi24"`
-	ClientsWifi5   uint32        `json:"clients_wifi5"`
-	ClientsOthers  uint32        `json:"clients_other"`
-	RootFSUsage    float64       `json:"rootfs_usage"`
-	LoadAverage    float64       `json:"loadavg"`
-	MemoryUsage    *float64      `json:"memory_usage,omitempty"`
-	Uptime         jsontime.Time `json:"uptime,omitempty"`
-	GatewayNexthop string        `json:"gateway_nexthop,omitempty"`
-	GatewayIPv4    string        `json:"gateway,omitempty"`
-	GatewayIPv6    string        `json:"gateway6,omitempty"`
-	NodeID         string        `json:"node_id"`
-	MAC            string        `json:"mac"`
-	Addresses      []string      `json:"addresses"`
-	SiteCode       string        `json:"site_code,omitempty"`
-	Hostname       string        `json:"hostname"`
-	Owner          string        `json:"owner,omitempty"`
-	Location       *Location     `json:"location,omitempty"`
-	Firmware       Firmware      `json:"firmware,omitempty"`
-	Autoupdater    Autoupdater   `json:"autoupdater"`
-	Nproc          int           `json:"nproc"`
-	Model          string        `json:"model,omitempty"`
-	VPN            bool          `json:"vpn"`
+	Firstseen      jsontime.Time     `json:"firstseen"`
+	Lastseen       jsontime.Time     `json:"lastseen"`
+	IsOnline       bool              `json:"is_online"`
+	IsGateway      bool              `json:"is_gateway"`
+	IsConflicting  bool              `json:"is_conflicting,omitempty"`
+	IsMaintenance  bool              `json:"is_maintenance,omitempty"`
+	Clients        uint32            `json:"clients"`
+	ClientsWifi24  uint32            `json:"clients_wifi24"`
+	ClientsWifi5   uint32            `json:"clients_wifi5"`
+	ClientsOthers  uint32            `json:"clients_other"`
+	RootFSUsage    float64           `json:"rootfs_usage"`
+	LoadAverage    float64           `json:"loadavg"`
+	MemoryUsage    *float64          `json:"memory_usage,omitempty"`
+	Rates          *data.Rates       `json:"rates,omitempty"`
+	Uptime         jsontime.Time     `json:"uptime,omitempty"`
+	GatewayNexthop string            `json:"gateway_nexthop,omitempty"`
+	GatewayIPv4    string            `json:"gateway,omitempty"`
+	GatewayIPv6    string            `json:"gateway6,omitempty"`
+	NodeID         string            `json:"node_id"`
+	MAC            string            `json:"mac"`
+	Addresses      []string          `json:"addresses"`
+	SiteCode       string            `json:"site_code,omitempty"`
+	DomainCode     string            `json:"domain_code,omitempty"`
+	Hostname       string            `json:"hostname"`
+	Owner          string            `json:"owner,omitempty"`
+	Location       *Location         `json:"location,omitempty"`
+	Firmware       Firmware          `json:"firmware,omitempty"`
+	Autoupdater    Autoupdater       `json:"autoupdater"`
+	Nproc          int               `json:"nproc"`
+	Model          string            `json:"model,omitempty"`
+	VPN            bool              `json:"vpn"`
+	Tags           map[string]string `json:"tags,omitempty"`
 }
 
 // Firmware out of software
@@ -78,6 +84,10 @@ func NewNode(nodes *runtime.Nodes, n *runtime.Node) *Node {
 		Lastseen:  n.Lastseen,
 		IsOnline:  n.Online,
 		IsGateway: n.IsGateway(),
+
+		IsConflicting: n.Conflict,
+		IsMaintenance: n.Maintenance,
+		Tags:          n.Tags,
 	}
 
 	if nodeinfo := n.Nodeinfo; nodeinfo != nil {
@@ -85,6 +95,7 @@ func NewNode(nodes *runtime.Nodes, n *runtime.Node) *Node {
 		node.MAC = nodeinfo.Network.Mac
 		node.Addresses = nodeinfo.Network.Addresses
 		node.SiteCode = nodeinfo.System.SiteCode
+		node.DomainCode = nodeinfo.System.DomainCode
 		node.Hostname = nodeinfo.Hostname
 		if owner := nodeinfo.Owner; owner != nil {
 			node.Owner = owner.Contact
@@ -92,7 +103,7 @@ func NewNode(nodes *runtime.Nodes, n *runtime.Node) *Node {
 		if location := nodeinfo.Location; location != nil {
 			node.Location = &Location{
 				Longitude: location.Longitude,
-				Latitude:   location.Latitude,
+				Latitude:  location.Latitude,
 			}
 		}
 		node.Firmware = nodeinfo.Software.Firmware
@@ -130,6 +141,7 @@ func NewNode(nodes *runtime.Nodes, n *runtime.Node) *Node {
 			node.MemoryUsage = &usage
 		}
 
+		node.Rates = statistic.Rates
 		node.Uptime = jsontime.Now().Add(time.Duration(statistic.Uptime) * -time.Second)
 		node.GatewayNexthop = nodes.GetNodeIDbyMAC(statistic.GatewayNexthop)
 		if node.GatewayNexthop == "" {
@@ -86,20 +86,12 @@ func (builder *graphBuilder) readNodes(nodes map[string]*runtime.Node) {
 	// Add links
 	for sourceID, node := range nodes {
 		if node.Online {
-			vpnInterface := make(map[string]interface{})
-			if nodeinfo := node.Nodeinfo; nodeinfo != nil {
-				for _, batinterface := range nodeinfo.Network.Mesh {
-					for _, vpn := range batinterface.Interfaces.Tunnel {
-						vpnInterface[vpn] = nil
-					}
-				}
-			}
 			if neighbours := node.Neighbours; neighbours != nil {
 				// Batman neighbours
 				for sourceMAC, batadvNeighbours := range neighbours.Batadv {
 					for targetAddress, link := range batadvNeighbours.Neighbours {
 						if targetID, found := builder.macToID[targetAddress]; found {
-							_, vpn := vpnInterface[sourceMAC]
+							vpn := runtime.IsVPNLink(node, sourceMAC, nodes[targetID], targetAddress)
 							builder.addLink(targetID, sourceID, link.Tq, vpn)
 						}
 					}
@@ -44,6 +44,7 @@ type Statistics struct {
 		MgmtTx  *data.Traffic `json:"mgmt_tx"`
 		MgmtRx  *data.Traffic `json:"mgmt_rx"`
 	} `json:"traffic,omitempty"`
+	Rates *data.Rates `json:"rates,omitempty"`
 }
 
 // NewStatistics transform respond Statistics to meshviewer Statistics
@@ -75,6 +76,7 @@ func NewStatistics(stats *data.Statistics) *Statistics {
 		Processes:   stats.Processes,
 		MeshVPN:     stats.MeshVPN,
 		Traffic:     stats.Traffic,
+		Rates:       stats.Rates,
 		Clients:     total,
 	}
 }
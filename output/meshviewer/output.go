@@ -10,8 +10,9 @@ import (
 
 type Output struct {
 	output.Output
-	config  Config
-	builder nodeBuilder
+	config    Config
+	builder   nodeBuilder
+	publisher *output.Publisher
 }
 
 type Config map[string]interface{}
@@ -29,7 +30,8 @@ func (c Config) NodesPath() string {
 	return c["nodes_path"].(string)
 }
 func (c Config) GraphPath() string {
-	return c["graph_path"].(string)
+	path, _ := c["graph_path"].(string)
+	return path
 }
 
 type nodeBuilder func(*runtime.Nodes) interface{}
@@ -40,7 +42,11 @@ var nodeFormats = map[int64]nodeBuilder{
 }
 
 func init() {
-	output.RegisterAdapter("meshviewer", Register)
+	output.RegisterAdapter("meshviewer", Register,
+		runtime.Option{Name: "version", Type: runtime.OptionInt, Default: int64(2), Doc: "Structure version of nodes.json: 1 for the legacy meshviewer, 2 for newer versions"},
+		runtime.Option{Name: "nodes_path", Type: runtime.OptionString, Required: true, Doc: "Path where to store nodes.json"},
+		runtime.Option{Name: "graph_path", Type: runtime.OptionString, Default: "", Doc: "Path where to store graph.json"},
+	)
 }
 
 func Register(configuration map[string]interface{}) (output.Output, error) {
@@ -52,21 +58,39 @@ func Register(configuration map[string]interface{}) (output.Output, error) {
 		return nil, fmt.Errorf("invalid nodes version: %d", config.Version())
 	}
 
+	publisher, err := output.NewPublisher(configuration)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Output{
-		config:  config,
-		builder: builder,
+		config:    config,
+		builder:   builder,
+		publisher: publisher,
 	}, nil
 }
 
-func (o *Output) Save(nodes *runtime.Nodes) {
+func (o *Output) Save(nodes *runtime.Nodes) error {
 	nodes.RLock()
 	defer nodes.RUnlock()
 
+	var files []output.File
 	if path := o.config.NodesPath(); path != "" {
-		runtime.SaveJSON(o.builder(nodes), path)
+		file, err := output.JSONFile(path, o.builder(nodes))
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
 	}
 
 	if path := o.config.GraphPath(); path != "" {
-		runtime.SaveJSON(BuildGraph(nodes), path)
+		file, err := output.JSONFile(path, BuildGraph(nodes))
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
 	}
+
+	// nodes.json and graph.json are published together
+	return o.publisher.Publish(files...)
 }
@@ -36,7 +36,7 @@ func TestOutput(t *testing.T) {
 	assert.NoError(err)
 	assert.NotNil(out)
 
-	out.Save(&runtime.Nodes{})
+	assert.NoError(out.Save(&runtime.Nodes{}))
 	_, err = os.Stat("/tmp/nodes.json")
 	assert.NoError(err)
 	_, err = os.Stat("/tmp/graph.json")
@@ -47,7 +47,8 @@ func NewNode(n *runtime.Node) (node *Node) {
 	return
 }
 
-func transform(nodes *runtime.Nodes) *NodeList {
+// Transform returns the nodelist of the nodes
+func Transform(nodes *runtime.Nodes) *NodeList {
 	nodelist := &NodeList{
 		Version:   "1.0.1",
 		Timestamp: jsontime.Now(),
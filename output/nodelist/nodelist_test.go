@@ -10,7 +10,7 @@ import (
 )
 
 func TestTransform(t *testing.T) {
-	nodes := transform(createTestNodes())
+	nodes := Transform(createTestNodes())
 
 	assert := assert.New(t)
 	assert.Len(nodes.List, 3)
@@ -9,7 +9,8 @@ import (
 
 type Output struct {
 	output.Output
-	path string
+	path      string
+	publisher *output.Publisher
 }
 
 type Config map[string]interface{}
@@ -22,7 +23,9 @@ func (c Config) Path() string {
 }
 
 func init() {
-	output.RegisterAdapter("nodelist", Register)
+	output.RegisterAdapter("nodelist", Register,
+		runtime.Option{Name: "path", Type: runtime.OptionString, Required: true, Doc: "Path where to store nodelist.json"},
+	)
 }
 
 func Register(configuration map[string]interface{}) (output.Output, error) {
@@ -30,17 +33,26 @@ func Register(configuration map[string]interface{}) (output.Output, error) {
 	config = configuration
 
 	if path := config.Path(); path != "" {
+		publisher, err := output.NewPublisher(configuration)
+		if err != nil {
+			return nil, err
+		}
 		return &Output{
-			path: path,
+			path:      path,
+			publisher: publisher,
 		}, nil
 	}
 	return nil, errors.New("no path given")
 
 }
 
-func (o *Output) Save(nodes *runtime.Nodes) {
+func (o *Output) Save(nodes *runtime.Nodes) error {
 	nodes.RLock()
 	defer nodes.RUnlock()
 
-	runtime.SaveJSON(transform(nodes), o.path)
+	file, err := output.JSONFile(o.path, Transform(nodes))
+	if err != nil {
+		return err
+	}
+	return o.publisher.Publish(file)
 }
@@ -22,7 +22,7 @@ func TestOutput(t *testing.T) {
 	assert.NoError(err)
 	assert.NotNil(out)
 
-	out.Save(&runtime.Nodes{})
+	assert.NoError(out.Save(&runtime.Nodes{}))
 	_, err = os.Stat("/tmp/nodelist.json")
 	assert.NoError(err)
 }
@@ -4,8 +4,8 @@ import "github.com/FreifunkBremen/yanic/runtime"
 
 // Output interface to use for implementation in e.g. influxdb
 type Output interface {
-	// InsertNode stores statistics per node
-	Save(nodes *runtime.Nodes)
+	// Save writes the nodes
+	Save(nodes *runtime.Nodes) error
 }
 
 // Register function with config to get a output interface
@@ -14,6 +14,26 @@ type Register func(config map[string]interface{}) (Output, error)
 // Adapters is the list of registered output adapters
 var Adapters = map[string]Register{}
 
-func RegisterAdapter(name string, n Register) {
+// Options of the registered output adapters, which declared them
+var Options = map[string]runtime.Options{}
+
+// CommonOptions are the options of every output
+var CommonOptions = runtime.Options{
+	{Name: "enable", Type: runtime.OptionBool, Default: true},
+	{Name: "filter", Type: runtime.OptionTable, Doc: "Filters of the nodes, see [nodes.output.<type>.filter]"},
+	{Name: "transform", Type: runtime.OptionTables, Doc: "Transformations of the nodes in this order, see [[nodes.output.<type>.transform]]"},
+	{Name: "save_interval", Type: runtime.OptionDuration, Doc: "Write interval of this output (default: nodes.save_interval)"},
+	{Name: "on_change", Type: runtime.OptionBool, Default: false, Doc: "Write only if a node of this output changed, instead of every save_interval"},
+	{Name: "debounce", Type: runtime.OptionDuration, Doc: "Wait after a change before writing to collect further changes (on_change)"},
+	{Name: "compress", Type: runtime.OptionList, Doc: "Write compressed files as well for static webservers: gzip (.gz) and brotli (.br)"},
+	{Name: "upload", Type: runtime.OptionTable, Doc: "Upload the files instead of writing them, see [nodes.output.<type>.upload]"},
+	{Name: "publish_dir", Type: runtime.OptionString, Doc: "Publish the files of the output at once by replacing this symlink to a new directory,\nthe paths of the files have to be inside of it"},
+}
+
+// RegisterAdapter registers an output, its configuration is validated against the options if given
+func RegisterAdapter(name string, n Register, options ...runtime.Option) {
 	Adapters[name] = n
+	if len(options) > 0 {
+		Options[name] = append(append(runtime.Options{}, CommonOptions...), options...)
+	}
 }
@@ -0,0 +1,275 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// File is a file written by an output
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// JSONFile returns the file with the input encoded as JSON
+func JSONFile(path string, input interface{}) (File, error) {
+	content, err := json.Marshal(input)
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %s", path, err)
+	}
+	return File{Path: path, Content: content}, nil
+}
+
+// compressions of the files by name, the compressed file is written next to the file with the extension
+var compressions = map[string]struct {
+	extension string
+	compress  func([]byte) ([]byte, error)
+}{
+	"gzip":   {".gz", gzipContent},
+	"brotli": {".br", brotliContent},
+}
+
+// versionSuffix separates publish_dir and the number of its versions
+const versionSuffix = ".v"
+
+// Publisher writes the files of an output as configured by the common options compress, publish_dir and upload
+type Publisher struct {
+	compress   []string
+	publishDir string
+	upload     *uploader
+}
+
+// NewPublisher creates the publisher of the configuration of an output
+func NewPublisher(config map[string]interface{}) (*Publisher, error) {
+	p := &Publisher{}
+	p.publishDir, _ = config["publish_dir"].(string)
+	list, _ := config["compress"].([]interface{})
+	for _, item := range list {
+		name, _ := item.(string)
+		if _, ok := compressions[name]; !ok {
+			return nil, fmt.Errorf("unknown compression %q, expected gzip or brotli", name)
+		}
+		p.compress = append(p.compress, name)
+	}
+	if table, ok := config["upload"].(map[string]interface{}); ok {
+		upload, err := newUploader(table)
+		if err != nil {
+			return nil, err
+		}
+		p.upload = upload
+	}
+	return p, nil
+}
+
+// Publish writes the files and their compressed versions.
+// Each file is replaced at once. With publish_dir all files are written to a new directory,
+// which replaces the previous one at once by a symlink. The other files of the previous one are kept.
+// With upload the files are uploaded instead.
+func (p *Publisher) Publish(files ...File) error {
+	if p.upload != nil {
+		return p.uploadFiles(files)
+	}
+	if p.publishDir != "" {
+		return p.publishVersion(files)
+	}
+	for _, file := range files {
+		if err := p.write(file.Path, file.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Paths returns the path of a published file and the paths of its compressed versions
+func (p *Publisher) Paths(path string) []string {
+	paths := []string{path}
+	for _, name := range p.compress {
+		paths = append(paths, path+compressions[name].extension)
+	}
+	return paths
+}
+
+// withCompressed returns the file and its compressed versions
+func (p *Publisher) withCompressed(path string, content []byte) ([]File, error) {
+	files := []File{{Path: path, Content: content}}
+	for _, name := range p.compress {
+		c := compressions[name]
+		compressed, err := c.compress(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %s", name, path, err)
+		}
+		files = append(files, File{Path: path + c.extension, Content: compressed})
+	}
+	return files, nil
+}
+
+// write writes the file and its compressed versions
+func (p *Publisher) write(path string, content []byte) error {
+	files, err := p.withCompressed(path, content)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := writeFile(file.Path, file.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadFiles uploads the files and their compressed versions.
+// Their names are the paths relative to publish_dir or the names of the files.
+func (p *Publisher) uploadFiles(files []File) error {
+	for _, file := range files {
+		name := filepath.Base(file.Path)
+		if p.publishDir != "" {
+			rel, err := filepath.Rel(p.publishDir, file.Path)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				name = filepath.ToSlash(rel)
+			}
+		}
+		compressed, err := p.withCompressed(name, file.Content)
+		if err != nil {
+			return err
+		}
+		for _, c := range compressed {
+			if err := p.upload.upload(c.Path, c.Content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// publishVersion writes the files to a new version of publish_dir and points the symlink publish_dir to it
+func (p *Publisher) publishVersion(files []File) error {
+	dir := filepath.Clean(p.publishDir)
+	version := dir + versionSuffix + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := os.Mkdir(version, 0755); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		rel, err := filepath.Rel(dir, filepath.Clean(file.Path))
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			os.RemoveAll(version)
+			return fmt.Errorf("%s is not inside of publish_dir %s", file.Path, dir)
+		}
+		path := filepath.Join(version, rel)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = p.write(path, file.Content)
+		}
+		if err != nil {
+			os.RemoveAll(version)
+			return err
+		}
+	}
+
+	// other outputs may write into publish_dir as well
+	if err := carryOver(dir, version); err != nil {
+		os.RemoveAll(version)
+		return err
+	}
+
+	link := dir + ".link"
+	os.Remove(link)
+	if err := os.Symlink(filepath.Base(version), link); err != nil {
+		os.RemoveAll(version)
+		return err
+	}
+	if err := os.Rename(link, dir); err != nil {
+		os.Remove(link)
+		os.RemoveAll(version)
+		return fmt.Errorf("unable to replace publish_dir, it has to be a symlink: %s", err)
+	}
+
+	// the previous version is kept for readers, which did not finish yet
+	versions, _ := filepath.Glob(dir + versionSuffix + "*")
+	sort.Strings(versions)
+	for i := 0; i < len(versions)-2; i++ {
+		if versions[i] != version {
+			os.RemoveAll(versions[i])
+		}
+	}
+	return nil
+}
+
+// carryOver links the files of the current version of publish_dir into the new version,
+// which are not written by this output
+func carryOver(dir, version string) error {
+	current, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		// there is no current version yet
+		return nil
+	}
+	return filepath.Walk(current, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return err
+		}
+		rel, err := filepath.Rel(current, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(version, rel)
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if os.Link(path, target) == nil {
+			return nil
+		}
+		// e.g. the file system does not support hard links
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, info.Mode())
+	})
+}
+
+// writeFile writes a temporary file and renames it to replace the file at once
+func writeFile(path string, content []byte) error {
+	tmpFile := path + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, content, 0644); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
+
+func gzipContent(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	err := w.Close()
+	return buf.Bytes(), err
+}
+
+func brotliContent(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	err := w.Close()
+	return buf.Bytes(), err
+}
@@ -0,0 +1,124 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishCompressed(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "yanic-publish")
+	defer os.RemoveAll(dir)
+
+	_, err := NewPublisher(map[string]interface{}{"compress": []interface{}{"zip"}})
+	assert.EqualError(err, "unknown compression \"zip\", expected gzip or brotli")
+
+	p, err := NewPublisher(map[string]interface{}{"compress": []interface{}{"gzip", "brotli"}})
+	assert.NoError(err)
+	file, err := JSONFile(filepath.Join(dir, "nodes.json"), map[string]int{"nodes": 1})
+	assert.NoError(err)
+	assert.NoError(p.Publish(file))
+
+	content, err := ioutil.ReadFile(file.Path)
+	assert.NoError(err)
+	assert.Equal(`{"nodes":1}`, string(content))
+
+	f, err := os.Open(file.Path + ".gz")
+	assert.NoError(err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	assert.NoError(err)
+	content, err = ioutil.ReadAll(r)
+	assert.NoError(err)
+	assert.Equal(`{"nodes":1}`, string(content))
+
+	compressed, err := ioutil.ReadFile(file.Path + ".br")
+	assert.NoError(err)
+	content, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(compressed)))
+	assert.NoError(err)
+	assert.Equal(`{"nodes":1}`, string(content))
+
+	// no temporary files are left
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(files, 3)
+
+	// errors are returned
+	err = p.Publish(File{Path: filepath.Join(dir, "missing", "nodes.json")})
+	assert.Error(err)
+
+	_, err = JSONFile("nodes.json", func() {})
+	assert.EqualError(err, "nodes.json: json: unsupported type: func()")
+}
+
+func TestPublishDir(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "yanic-publish")
+	defer os.RemoveAll(dir)
+	data := filepath.Join(dir, "data")
+
+	p, err := NewPublisher(map[string]interface{}{"publish_dir": data, "compress": []interface{}{"gzip"}})
+	assert.NoError(err)
+
+	publish := func(content string) error {
+		return p.Publish(
+			File{Path: filepath.Join(data, "nodes.json"), Content: []byte(content)},
+			File{Path: filepath.Join(data, "graph", "graph.json"), Content: []byte(content)},
+		)
+	}
+	read := func(name string) string {
+		content, _ := ioutil.ReadFile(filepath.Join(data, name))
+		return string(content)
+	}
+
+	for _, content := range []string{"1", "2", "3"} {
+		assert.NoError(publish(content))
+		assert.Equal(content, read("nodes.json"))
+		assert.Equal(content, read("graph/graph.json"))
+		_, err = os.Stat(filepath.Join(data, "nodes.json.gz"))
+		assert.NoError(err)
+	}
+
+	// the current and the previous version are kept
+	versions, _ := filepath.Glob(data + versionSuffix + "*")
+	assert.Len(versions, 2)
+	target, err := os.Readlink(data)
+	assert.NoError(err)
+	assert.Equal(filepath.Base(versions[1]), target)
+
+	// the files of other outputs are kept
+	shared, _ := NewPublisher(map[string]interface{}{"publish_dir": data})
+	assert.NoError(shared.Publish(File{Path: filepath.Join(data, "nodelist.json"), Content: []byte("list")}))
+	assert.NoError(writeFile(filepath.Join(data, "direct.json"), []byte("direct")))
+	assert.NoError(publish("4"))
+	assert.Equal("4", read("nodes.json"))
+	assert.Equal("list", read("nodelist.json"))
+	assert.Equal("direct", read("direct.json"))
+	assert.NoError(shared.Publish(File{Path: filepath.Join(data, "nodelist.json"), Content: []byte("list2")}))
+	assert.Equal("4", read("graph/graph.json"))
+	assert.Equal("list2", read("nodelist.json"))
+	assert.Equal("4", read("nodes.json"))
+
+	// files outside of publish_dir
+	err = p.Publish(File{Path: filepath.Join(dir, "nodes.json")})
+	assert.Contains(err.Error(), "is not inside of publish_dir")
+	assert.Equal("4", read("nodes.json"))
+	versions, _ = filepath.Glob(data + versionSuffix + "*")
+	assert.Len(versions, 2)
+
+	// a directory is not replaced
+	other := filepath.Join(dir, "other")
+	os.Mkdir(other, 0755)
+	ioutil.WriteFile(filepath.Join(other, "nodes.json"), nil, 0644)
+	p, _ = NewPublisher(map[string]interface{}{"publish_dir": other})
+	err = p.Publish(File{Path: filepath.Join(other, "nodes.json")})
+	assert.Contains(err.Error(), "unable to replace publish_dir, it has to be a symlink")
+}
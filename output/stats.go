@@ -0,0 +1,34 @@
+package output
+
+import "time"
+
+// Stats are the self-metrics of an output
+type Stats struct {
+	Saves     uint64    `json:"saves"`                // writes including the failed ones
+	Errors    uint64    `json:"errors"`               // failed writes
+	LastSave  time.Time `json:"last_save"`            // start of the last write
+	LastError string    `json:"last_error,omitempty"` // error of the last failed write
+
+	DurationSum   time.Duration `json:"-"`
+	DurationMax   time.Duration `json:"-"`
+	DurationLastS float64       `json:"duration_last"` // duration of the last write in seconds
+	DurationAvgS  float64       `json:"duration_avg"`  // average duration of the writes in seconds
+	DurationMaxS  float64       `json:"duration_max"`  // longest duration of the writes in seconds
+}
+
+// Add records a write started at the given time
+func (s *Stats) Add(start time.Time, duration time.Duration, err error) {
+	s.Saves++
+	s.LastSave = start
+	if err != nil {
+		s.Errors++
+		s.LastError = err.Error()
+	}
+	s.DurationSum += duration
+	if duration > s.DurationMax {
+		s.DurationMax = duration
+	}
+	s.DurationLastS = duration.Seconds()
+	s.DurationAvgS = (s.DurationSum / time.Duration(s.Saves)).Seconds()
+	s.DurationMaxS = s.DurationMax.Seconds()
+}
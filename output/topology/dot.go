@@ -0,0 +1,37 @@
+package topology
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dotEscaper escapes the quoted strings of DOT.
+// A backslash can not be escaped, it is replaced: it would start an escape sequence of a label (e.g. \N)
+// or escape the closing quote.
+var dotEscaper = strings.NewReplacer(`\`, "/", `"`, `\"`, "\n", `\n`, "\r", "")
+
+// DOT returns the topology as undirected graph of Graphviz
+func (t *Topology) DOT() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("graph mesh {\n")
+	for _, node := range t.Nodes {
+		label := node.Hostname
+		if label == "" {
+			label = node.ID
+		}
+		fmt.Fprintf(&buf, "\t%s [label=%s, hostname=%s, site=%s, gateway=%t, online=%t];\n",
+			dotQuote(node.ID), dotQuote(label), dotQuote(node.Hostname), dotQuote(node.Site), node.Gateway, node.Online)
+	}
+	for _, edge := range t.Edges {
+		fmt.Fprintf(&buf, "\t%s -- %s [tq=%s, type=%s, bidirectional=%t];\n",
+			dotQuote(edge.Source), dotQuote(edge.Target), strconv.FormatFloat(edge.TQ, 'f', 3, 64), dotQuote(edge.Type), edge.Bidirectional)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
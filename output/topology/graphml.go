@@ -0,0 +1,92 @@
+package topology
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+)
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys declare the attributes of the nodes and edges
+var graphMLKeys = []graphMLKey{
+	{ID: "label", For: "node", Name: "label", Type: "string"},
+	{ID: "hostname", For: "node", Name: "hostname", Type: "string"},
+	{ID: "site", For: "node", Name: "site", Type: "string"},
+	{ID: "gateway", For: "node", Name: "gateway", Type: "boolean"},
+	{ID: "online", For: "node", Name: "online", Type: "boolean"},
+	{ID: "tq", For: "edge", Name: "tq", Type: "double"},
+	{ID: "type", For: "edge", Name: "type", Type: "string"},
+	{ID: "bidirectional", For: "edge", Name: "bidirectional", Type: "boolean"},
+}
+
+// GraphML returns the topology as undirected graph of GraphML
+func (t *Topology) GraphML() ([]byte, error) {
+	g := graphML{XMLNS: "http://graphml.graphdrawing.org/xmlns", Keys: graphMLKeys}
+	g.Graph.ID = "mesh"
+	g.Graph.EdgeDefault = "undirected"
+
+	for _, node := range t.Nodes {
+		label := node.Hostname
+		if label == "" {
+			label = node.ID
+		}
+		g.Graph.Nodes = append(g.Graph.Nodes, graphMLNode{ID: node.ID, Data: []graphMLData{
+			{"label", label},
+			{"hostname", node.Hostname},
+			{"site", node.Site},
+			{"gateway", strconv.FormatBool(node.Gateway)},
+			{"online", strconv.FormatBool(node.Online)},
+		}})
+	}
+	for _, edge := range t.Edges {
+		g.Graph.Edges = append(g.Graph.Edges, graphMLEdge{Source: edge.Source, Target: edge.Target, Data: []graphMLData{
+			{"tq", strconv.FormatFloat(edge.TQ, 'f', 3, 64)},
+			{"type", edge.Type},
+			{"bidirectional", strconv.FormatBool(edge.Bidirectional)},
+		}})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(g); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
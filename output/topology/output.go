@@ -0,0 +1,84 @@
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type Output struct {
+	output.Output
+	path      string
+	graphML   bool
+	publisher *output.Publisher
+}
+
+type Config map[string]interface{}
+
+func (c Config) Path() string {
+	path, _ := c["path"].(string)
+	return path
+}
+
+// Format returns dot or graphml, by default the extension of the path decides
+func (c Config) Format() string {
+	if format, ok := c["format"].(string); ok && format != "" {
+		return format
+	}
+	if strings.ToLower(filepath.Ext(c.Path())) == ".graphml" {
+		return "graphml"
+	}
+	return "dot"
+}
+
+func init() {
+	output.RegisterAdapter("topology", Register,
+		runtime.Option{Name: "path", Type: runtime.OptionString, Required: true, Doc: "Path where to store the topology, e.g. mesh.dot or mesh.graphml"},
+		runtime.Option{Name: "format", Type: runtime.OptionString, Doc: "dot (Graphviz) or graphml (default: by the extension of path)"},
+	)
+}
+
+func Register(configuration map[string]interface{}) (output.Output, error) {
+	var config Config
+	config = configuration
+
+	path := config.Path()
+	if path == "" {
+		return nil, errors.New("no path given")
+	}
+	format := config.Format()
+	if format != "dot" && format != "graphml" {
+		return nil, fmt.Errorf("unknown format %q, expected dot or graphml", format)
+	}
+	publisher, err := output.NewPublisher(configuration)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{
+		path:      path,
+		graphML:   format == "graphml",
+		publisher: publisher,
+	}, nil
+}
+
+func (o *Output) Save(nodes *runtime.Nodes) error {
+	nodes.RLock()
+	topology := Build(nodes)
+	nodes.RUnlock()
+
+	var content []byte
+	var err error
+	if o.graphML {
+		content, err = topology.GraphML()
+	} else {
+		content = topology.DOT()
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", o.path, err)
+	}
+	return o.publisher.Publish(output.File{Path: o.path, Content: content})
+}
@@ -0,0 +1,73 @@
+package topology
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDOT(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`graph mesh {
+	"a" [label="gw-a", hostname="gw-a", site="ffhb", gateway=true, online=true];
+	"b" [label="node-b", hostname="node-b", site="ffhb", gateway=false, online=true];
+	"c" [label="node-\"c\"", hostname="node-\"c\"", site="ffhb", gateway=false, online=true];
+	"d" [label="d", hostname="", site="ffhb", gateway=false, online=false];
+	"a" -- "b" [tq=0.784, type="vpn", bidirectional=false];
+	"a" -- "c" [tq=1.000, type="other", bidirectional=false];
+	"b" -- "c" [tq=0.800, type="wifi", bidirectional=true];
+}
+`, string(Build(createTestNodes()).DOT()))
+
+	// a backslash would escape the closing quote
+	assert.Equal(`"node/\"c\"/"`, dotQuote(`node\"c"\`))
+}
+
+func TestGraphML(t *testing.T) {
+	assert := assert.New(t)
+
+	content, err := Build(createTestNodes()).GraphML()
+	assert.NoError(err)
+	assert.Contains(string(content), `<key id="tq" for="edge" attr.name="tq" attr.type="double"></key>`)
+	assert.Contains(string(content), `<data key="hostname">node-&#34;c&#34;</data>`)
+
+	var g graphML
+	assert.NoError(xml.Unmarshal(content, &g))
+	assert.Equal("undirected", g.Graph.EdgeDefault)
+	assert.Len(g.Graph.Nodes, 4)
+	assert.Len(g.Graph.Edges, 3)
+	edge := g.Graph.Edges[2]
+	assert.Equal("b", edge.Source)
+	assert.Equal("c", edge.Target)
+	assert.Equal([]graphMLData{{"tq", "0.800"}, {"type", "wifi"}, {"bidirectional", "true"}}, edge.Data)
+}
+
+func TestOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, _ := ioutil.TempDir("", "yanic-topology")
+	defer os.RemoveAll(dir)
+
+	out, err := Register(map[string]interface{}{})
+	assert.EqualError(err, "no path given")
+	assert.Nil(out)
+
+	_, err = Register(map[string]interface{}{"path": "mesh.gexf", "format": "gexf"})
+	assert.EqualError(err, "unknown format \"gexf\", expected dot or graphml")
+
+	for name, prefix := range map[string]string{"mesh.dot": "graph mesh {", "mesh.graphml": xml.Header} {
+		path := filepath.Join(dir, name)
+		out, err = Register(map[string]interface{}{"path": path})
+		assert.NoError(err)
+		assert.NoError(out.Save(createTestNodes()))
+
+		content, err := ioutil.ReadFile(path)
+		assert.NoError(err)
+		assert.Contains(string(content), prefix)
+	}
+}
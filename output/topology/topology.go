@@ -0,0 +1,136 @@
+package topology
+
+import (
+	"sort"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Topology of the mesh with the nodes and the links between them
+type Topology struct {
+	Nodes []*Node
+	Edges []*Edge
+}
+
+// Node of the topology
+type Node struct {
+	ID       string
+	Hostname string
+	Site     string
+	Gateway  bool
+	Online   bool
+}
+
+// Edge is the link between two nodes, the source has the lower ID
+type Edge struct {
+	Source        string
+	Target        string
+	TQ            float64 // lowest link quality of both directions, 1.0 is the best
+	Type          string  // wifi, vpn or other
+	Bidirectional bool    // both nodes reported the link
+	reported      string  // ID of the node, which reported the link first
+}
+
+// priorities of the link types, a link with different types in each direction gets the higher one
+var linkTypes = map[string]int{"other": 0, "wifi": 1, "vpn": 2}
+
+// Build returns the batman-adv and LLDP topology of the nodes, links are read from online nodes only
+func Build(nodes *runtime.Nodes) *Topology {
+	lldpToID := make(map[string]string)
+	ids := make([]string, 0, len(nodes.List))
+	for id, node := range nodes.List {
+		ids = append(ids, id)
+		if neighbours := node.Neighbours; neighbours != nil {
+			for mac := range neighbours.LLDP {
+				lldpToID[mac] = id
+			}
+		}
+	}
+	sort.Strings(ids)
+
+	topology := &Topology{}
+	edges := make(map[[2]string]*Edge)
+	for _, id := range ids {
+		node := nodes.List[id]
+		topology.Nodes = append(topology.Nodes, newNode(id, node))
+		if !node.Online || node.Neighbours == nil {
+			continue
+		}
+
+		wireless := make(map[string]bool)
+		if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+			for _, mesh := range nodeinfo.Network.Mesh {
+				if mesh == nil {
+					continue
+				}
+				for _, mac := range mesh.Interfaces.Wireless {
+					wireless[mac] = true
+				}
+			}
+		}
+
+		for _, link := range nodes.NodeLinks(node) {
+			if link.TargetID == id {
+				continue
+			}
+			linkType := "other"
+			if link.VPN {
+				linkType = "vpn"
+			} else if wireless[link.SourceMAC] {
+				linkType = "wifi"
+			}
+			addEdge(edges, id, link.TargetID, float64(link.TQ)/255.0, linkType)
+		}
+		for _, lldp := range node.Neighbours.LLDP {
+			for targetMAC := range lldp {
+				targetID, ok := lldpToID[targetMAC]
+				if !ok {
+					targetID = nodes.GetNodeIDbyMAC(targetMAC)
+				}
+				if targetID != "" && targetID != id {
+					addEdge(edges, id, targetID, 1.0, "other")
+				}
+			}
+		}
+	}
+
+	for _, edge := range edges {
+		topology.Edges = append(topology.Edges, edge)
+	}
+	sort.Slice(topology.Edges, func(i, j int) bool {
+		a, b := topology.Edges[i], topology.Edges[j]
+		return a.Source < b.Source || a.Source == b.Source && a.Target < b.Target
+	})
+	return topology
+}
+
+func newNode(id string, node *runtime.Node) *Node {
+	n := &Node{ID: id, Gateway: node.IsGateway(), Online: node.Online}
+	if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+		n.Hostname = nodeinfo.Hostname
+		n.Site = nodeinfo.System.SiteCode
+	}
+	return n
+}
+
+// addEdge adds the link reported by the node with the ID from, a link of both nodes is merged
+func addEdge(edges map[[2]string]*Edge, from, to string, tq float64, linkType string) {
+	key := [2]string{from, to}
+	if to < from {
+		key = [2]string{to, from}
+	}
+	edge, ok := edges[key]
+	if !ok {
+		edges[key] = &Edge{Source: key[0], Target: key[1], TQ: tq, Type: linkType, reported: from}
+		return
+	}
+	if edge.reported != from {
+		edge.Bidirectional = true
+	}
+	if tq < edge.TQ {
+		edge.TQ = tq
+	}
+	if linkTypes[linkType] > linkTypes[edge.Type] {
+		edge.Type = linkType
+	}
+}
@@ -0,0 +1,97 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func testNode(id, hostname string, online bool, wireless, tunnel []string) *runtime.Node {
+	mesh := &data.BatInterface{}
+	mesh.Interfaces.Wireless = wireless
+	mesh.Interfaces.Tunnel = tunnel
+	return &runtime.Node{
+		Online: online,
+		Nodeinfo: &data.NodeInfo{
+			NodeID:   id,
+			Hostname: hostname,
+			System:   data.System{SiteCode: "ffhb"},
+			Network:  data.Network{Mesh: map[string]*data.BatInterface{"bat0": mesh}},
+		},
+		Neighbours: &data.Neighbours{NodeID: id},
+	}
+}
+
+func batadv(neighbours map[string]map[string]int) map[string]data.BatadvNeighbours {
+	result := make(map[string]data.BatadvNeighbours)
+	for source, targets := range neighbours {
+		links := make(map[string]data.BatmanLink)
+		for target, tq := range targets {
+			links[target] = data.BatmanLink{Tq: tq}
+		}
+		result[source] = data.BatadvNeighbours{Neighbours: links}
+	}
+	return result
+}
+
+func createTestNodes() *runtime.Nodes {
+	a := testNode("a", "gw-a", true, nil, []string{"a:t"})
+	a.Nodeinfo.VPN = true
+	a.Neighbours.LLDP = map[string]data.LLDPNeighbours{"a:e": {"c:e": {}}}
+
+	b := testNode("b", "node-b", true, []string{"b:w"}, []string{"b:t"})
+	b.Neighbours.Batadv = batadv(map[string]map[string]int{
+		"b:t": {"a:t": 200},
+		"b:w": {"c:w": 255, "unknown": 255},
+	})
+
+	c := testNode("c", "node-\"c\"", true, []string{"c:w"}, nil)
+	c.Neighbours.Batadv = batadv(map[string]map[string]int{"c:w": {"b:w": 204}})
+	c.Neighbours.LLDP = map[string]data.LLDPNeighbours{"c:e": {}}
+
+	// links of offline nodes are ignored
+	d := testNode("d", "", false, []string{"d:w"}, nil)
+	d.Neighbours.Batadv = batadv(map[string]map[string]int{"d:w": {"c:w": 100}})
+
+	nodes := runtime.NewNodes(&runtime.Config{})
+	for _, node := range []*runtime.Node{a, b, c, d} {
+		nodes.AddNode(node)
+	}
+	return nodes
+}
+
+func TestBuild(t *testing.T) {
+	assert := assert.New(t)
+
+	topology := Build(createTestNodes())
+
+	assert.Len(topology.Nodes, 4)
+	assert.Equal(&Node{ID: "a", Hostname: "gw-a", Site: "ffhb", Gateway: true, Online: true}, topology.Nodes[0])
+	assert.Equal("d", topology.Nodes[3].ID)
+	assert.False(topology.Nodes[3].Online)
+
+	assert.Len(topology.Edges, 3)
+	edge := topology.Edges[0]
+	assert.Equal("a", edge.Source)
+	assert.Equal("b", edge.Target)
+	assert.Equal("vpn", edge.Type)
+	assert.InDelta(200.0/255.0, edge.TQ, 0.0001)
+	assert.False(edge.Bidirectional)
+
+	// LLDP
+	edge = topology.Edges[1]
+	assert.Equal("a", edge.Source)
+	assert.Equal("c", edge.Target)
+	assert.Equal("other", edge.Type)
+	assert.Equal(1.0, edge.TQ)
+
+	// reported by both nodes, the lowest link quality is used
+	edge = topology.Edges[2]
+	assert.Equal("b", edge.Source)
+	assert.Equal("c", edge.Target)
+	assert.Equal("wifi", edge.Type)
+	assert.Equal(0.8, edge.TQ)
+	assert.True(edge.Bidirectional)
+}
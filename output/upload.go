@@ -0,0 +1,215 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// UploadOptions are the options of [nodes.output.<type>.upload]
+var UploadOptions = runtime.Options{
+	{Name: "url", Type: runtime.OptionString, Required: true, Doc: "Target of the files: http(s)://host/path/ (HTTP PUT, WebDAV) or s3://bucket/prefix/"},
+	{Name: "username", Type: runtime.OptionString, Doc: "User of the HTTP basic authentication"},
+	{Name: "password", Type: runtime.OptionString, Doc: "Password of the HTTP basic authentication"},
+	{Name: "endpoint", Type: runtime.OptionString, Doc: "URL of the S3-compatible service (default: https://s3.<region>.amazonaws.com)"},
+	{Name: "region", Type: runtime.OptionString, Default: "us-east-1", Doc: "Region of the S3 bucket"},
+	{Name: "access_key", Type: runtime.OptionString, Doc: "Access key of S3"},
+	{Name: "secret_key", Type: runtime.OptionString, Doc: "Secret key of S3"},
+	{Name: "cache_control", Type: runtime.OptionString, Doc: "Cache-Control header of the files, e.g. \"max-age=60\""},
+	{Name: "retries", Type: runtime.OptionInt, Default: int64(3), Doc: "Retries of a failed upload"},
+	{Name: "retry_delay", Type: runtime.OptionDuration, Default: "1s", Doc: "Delay before the first retry, it increases with each retry"},
+	{Name: "timeout", Type: runtime.OptionDuration, Default: "30s", Doc: "Timeout of each request"},
+}
+
+// contentTypes by the extension of the file, the builtin types of mime are used for others
+var contentTypes = map[string]string{
+	".json":    "application/json",
+	".csv":     "text/csv; charset=utf-8",
+	".xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".dot":     "text/vnd.graphviz",
+	".graphml": "application/graphml+xml",
+	".gz":      "application/gzip",
+	".br":      "application/x-brotli",
+}
+
+// contentEncodings by the extension of a compressed file, they are set as metadata of S3 objects.
+// On other servers the header would declare the request body to be compressed.
+var contentEncodings = map[string]string{
+	".gz": "gzip",
+	".br": "br",
+}
+
+// uploader stores the files of an output on a remote server
+type uploader struct {
+	target       *url.URL // WebDAV collection or S3 endpoint with the bucket and prefix as path
+	s3           *s3Credentials
+	username     string
+	password     string
+	cacheControl string
+	retries      int
+	retryDelay   time.Duration
+	client       *http.Client
+}
+
+// newUploader creates the uploader of the configuration [nodes.output.<type>.upload]
+func newUploader(config map[string]interface{}) (*uploader, error) {
+	rawURL, _ := config["url"].(string)
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload url: %s", err)
+	}
+	u := &uploader{
+		retries:    3,
+		retryDelay: time.Second,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+	u.username, _ = config["username"].(string)
+	u.password, _ = config["password"].(string)
+	u.cacheControl, _ = config["cache_control"].(string)
+	if retries, ok := config["retries"].(int64); ok {
+		if retries < 0 {
+			return nil, fmt.Errorf("invalid upload retries %d, expected 0 or more", retries)
+		}
+		u.retries = int(retries)
+	}
+	if value, ok := config["retry_delay"]; ok {
+		var delay runtime.Duration
+		if err := delay.UnmarshalTOML(value); err == nil {
+			u.retryDelay = delay.Duration
+		}
+	}
+	if value, ok := config["timeout"]; ok {
+		var timeout runtime.Duration
+		if err := timeout.UnmarshalTOML(value); err == nil {
+			u.client.Timeout = timeout.Duration
+		}
+	}
+
+	switch target.Scheme {
+	case "http", "https":
+		u.target = target
+	case "s3":
+		u.s3, u.target, err = newS3(target, config)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid upload url %q, expected http(s):// or s3://", rawURL)
+	}
+	return u, nil
+}
+
+// upload stores the content with the name relative to the target
+func (u *uploader) upload(name string, content []byte) error {
+	var err error
+	for attempt := 0; attempt <= u.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(u.retryDelay * time.Duration(attempt))
+		}
+		var retry bool
+		if retry, err = u.put(name, content); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// put sends one request and returns whether it should be retried on error
+func (u *uploader) put(name string, content []byte) (bool, error) {
+	target := u.url(name)
+	req, err := http.NewRequest(http.MethodPut, target.String(), bytes.NewReader(content))
+	if err != nil {
+		return false, err
+	}
+	ext := path.Ext(name)
+	if encoding, ok := contentEncodings[ext]; ok && u.s3 != nil {
+		req.Header.Set("Content-Encoding", encoding)
+		ext = path.Ext(strings.TrimSuffix(name, ext))
+	}
+	req.Header.Set("Content-Type", contentType(ext))
+	if u.cacheControl != "" {
+		req.Header.Set("Cache-Control", u.cacheControl)
+	}
+	if u.s3 != nil {
+		u.s3.sign(req, content, time.Now())
+	} else if u.username != "" {
+		req.SetBasicAuth(u.username, u.password)
+	}
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return false, nil
+	case res.StatusCode == http.StatusConflict && u.s3 == nil && strings.Contains(name, "/"):
+		// the parent collection of WebDAV is missing, the upload is retried after creating it
+		if err := u.mkcol(name); err != nil {
+			return false, err
+		}
+		return true, fmt.Errorf("PUT %s: %s", target, res.Status)
+	case res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("PUT %s: %s", target, res.Status)
+	}
+	return false, fmt.Errorf("PUT %s: %s", target, res.Status)
+}
+
+// mkcol creates the parent collections of the name on a WebDAV server
+func (u *uploader) mkcol(name string) error {
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		target := u.url(strings.Join(parts[:i], "/") + "/")
+		req, err := http.NewRequest("MKCOL", target.String(), nil)
+		if err != nil {
+			return err
+		}
+		if u.username != "" {
+			req.SetBasicAuth(u.username, u.password)
+		}
+		res, err := u.client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		// 405 Method Not Allowed is returned for existing collections
+		if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("MKCOL %s: %s", target, res.Status)
+		}
+	}
+	return nil
+}
+
+// url returns the URL of the name relative to the target
+func (u *uploader) url(name string) *url.URL {
+	target := *u.target
+	target.Path = path.Join("/", target.Path, name)
+	if strings.HasSuffix(name, "/") {
+		target.Path += "/"
+	}
+	target.RawPath = ""
+	return &target
+}
+
+// contentType returns the content type of the extension
+func contentType(ext string) string {
+	if t, ok := contentTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
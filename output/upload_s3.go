@@ -0,0 +1,141 @@
+package output
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Credentials sign the requests to a S3-compatible service with AWS Signature Version 4
+type s3Credentials struct {
+	accessKey string
+	secretKey string
+	region    string
+}
+
+// newS3 returns the credentials and the URL of the bucket (path-style) of a s3://bucket/prefix URL
+func newS3(target *url.URL, config map[string]interface{}) (*s3Credentials, *url.URL, error) {
+	if target.Host == "" {
+		return nil, nil, errors.New("no bucket in the upload url")
+	}
+	c := &s3Credentials{region: "us-east-1"}
+	c.accessKey, _ = config["access_key"].(string)
+	c.secretKey, _ = config["secret_key"].(string)
+	if region, ok := config["region"].(string); ok && region != "" {
+		c.region = region
+	}
+	if c.accessKey == "" || c.secretKey == "" {
+		return nil, nil, errors.New("access_key and secret_key are required by s3")
+	}
+
+	endpoint, _ := config["endpoint"].(string)
+	if endpoint == "" {
+		endpoint = "https://s3." + c.region + ".amazonaws.com"
+	}
+	bucket, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid endpoint: %s", err)
+	}
+	bucket.Path = strings.TrimSuffix(bucket.Path, "/") + "/" + target.Host + target.Path
+	return c, bucket, nil
+}
+
+// sign adds the headers of the signature to the request
+func (c *s3Credentials) sign(req *http.Request, payload []byte, now time.Time) {
+	now = now.UTC()
+	hash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hash[:]))
+	req.URL.RawPath = awsEscape(req.URL.Path, false)
+
+	req.Header.Set("Authorization", c.authorization(req, now))
+}
+
+// authorization returns the Authorization header of the request, all its headers are signed
+func (c *s3Credentials) authorization(req *http.Request, now time.Time) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name != "authorization" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders string
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsEscape(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	date := now.Format("20060102")
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the sorted and escaped query
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(name, true)+"="+awsEscape(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape escapes all characters except the unreserved ones of RFC 3986 (and the slash if wanted)
+func awsEscape(s string, escapeSlash bool) string {
+	var escaped []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !escapeSlash) {
+			escaped = append(escaped, c)
+		} else {
+			escaped = append(escaped, fmt.Sprintf("%%%02X", c)...)
+		}
+	}
+	return string(escaped)
+}
@@ -0,0 +1,83 @@
+package respond
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// captureRecord is a received response in a capture file
+type captureRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Address   string    `json:"address"`
+	Raw       []byte    `json:"raw"` // deflated as received
+}
+
+// Capture writes every received response to a file
+type Capture struct {
+	file    *os.File
+	encoder *json.Encoder
+	sync.Mutex
+}
+
+// NewCapture opens the capture file and appends to it
+func NewCapture(path string) (*Capture, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Capture{
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// Write appends the response to the capture
+func (c *Capture) Write(res *Response) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.encoder.Encode(&captureRecord{
+		Timestamp: res.Received,
+		Address:   res.Address.String(),
+		Raw:       res.Raw,
+	})
+}
+
+// Close the capture file
+func (c *Capture) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.file.Close()
+}
+
+// ReadCapture calls f for every response in the capture file
+func ReadCapture(path string, f func(*Response)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	for {
+		record := &captureRecord{}
+		if err := decoder.Decode(record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		addr, err := net.ResolveUDPAddr("udp", record.Address)
+		if err != nil {
+			return err
+		}
+		f(&Response{
+			Address:  addr,
+			Raw:      record.Raw,
+			Received: record.Timestamp,
+		})
+	}
+}
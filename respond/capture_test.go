@@ -0,0 +1,56 @@
+package respond
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureAndReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	compressed, err := ioutil.ReadFile("testdata/nodeinfo.flated")
+	assert.NoError(err)
+
+	tmpfile, _ := ioutil.TempFile("/tmp", "capture")
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	capture, err := NewCapture(tmpfile.Name())
+	assert.NoError(err)
+
+	received := time.Date(2017, 3, 10, 12, 12, 1, 0, time.UTC)
+	err = capture.Write(&Response{
+		Address:  &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001, Zone: "eth0"},
+		Raw:      compressed,
+		Received: received,
+	})
+	assert.NoError(err)
+	assert.NoError(capture.Close())
+
+	// read capture
+	var responses []*Response
+	err = ReadCapture(tmpfile.Name(), func(res *Response) {
+		responses = append(responses, res)
+	})
+	assert.NoError(err)
+	assert.Len(responses, 1)
+	assert.Equal("[fe80::1%eth0]:1001", responses[0].Address.String())
+	assert.Equal(compressed, responses[0].Raw)
+	assert.True(received.Equal(responses[0].Received))
+
+	// replay capture
+	nodes := runtime.NewNodes(&runtime.Config{})
+	collector := NewCollector(nil, nodes, []string{}, []string{}, 0)
+	assert.NoError(collector.Replay(tmpfile.Name(), true))
+	assert.NotNil(nodes.List["f81a67a5e9c1"])
+
+	// not existing capture
+	collector = NewCollector(nil, nodes, []string{}, []string{}, 0)
+	assert.Error(collector.Replay("/tmp/not-existing-capture", false))
+}
@@ -1,12 +1,12 @@
 package respond
 
 import (
-	"bytes"
-	"compress/flate"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/FreifunkBremen/yanic/data"
@@ -25,12 +25,22 @@ type Collector struct {
 	db       database.Connection
 	nodes    *runtime.Nodes
 	sites    []string
+	domains  []string
 	interval time.Duration // Interval for multicast packets
-	stop     chan interface{}
+	unicast  runtime.UnicastConfig
+	metrics  *metrics
+	capture  *Capture     // optional capture of all received responses
+	mutex    sync.RWMutex // guards the sockets, sites, domains and unicast config, which may change while running
+
+	split      map[string]time.Time // addresses of nodes whose response does not fit into one datagram, with the time of the last truncated response
+	splitMutex sync.Mutex
+	stop       chan interface{}
+	done       chan interface{} // closed after the parser has processed the queue
+	receivers  sync.WaitGroup   // running receivers, which send to the queue
 }
 
 // NewCollector creates a Collector struct
-func NewCollector(db database.Connection, nodes *runtime.Nodes, sites []string , ifaces []string, port int) *Collector {
+func NewCollector(db database.Connection, nodes *runtime.Nodes, sites []string, ifaces []string, port int) *Collector {
 
 	coll := &Collector{
 		db:          db,
@@ -38,12 +48,17 @@ func NewCollector(db database.Connection, nodes *runtime.Nodes, sites []string ,
 		sites:       sites,
 		port:        port,
 		queue:       make(chan *Response, 400),
+		metrics:     newMetrics(),
+		split:       make(map[string]time.Time),
 		stop:        make(chan interface{}),
+		done:        make(chan interface{}),
 		ifaceToConn: make(map[string]*net.UDPConn),
 	}
 
 	for _, iface := range ifaces {
-		coll.listenUDP(iface)
+		if err := coll.listenUDP(iface); err != nil {
+			log.Panic(err)
+		}
 	}
 
 	go coll.parser()
@@ -55,13 +70,14 @@ func NewCollector(db database.Connection, nodes *runtime.Nodes, sites []string ,
 	return coll
 }
 
-func (coll *Collector) listenUDP(iface string) {
+// listenUDP opens a socket on the interface, the caller has to hold the lock if the collector is running
+func (coll *Collector) listenUDP(iface string) error {
 	if _, found := coll.ifaceToConn[iface]; found {
-		log.Panicf("can not listen twice on %s", iface)
+		return fmt.Errorf("can not listen twice on %s", iface)
 	}
 	linkLocalAddr, err := getLinkLocalAddr(iface)
 	if err != nil {
-		log.Panic(err)
+		return err
 	}
 
 	// Open socket
@@ -71,15 +87,79 @@ func (coll *Collector) listenUDP(iface string) {
 		Zone: iface,
 	})
 	if err != nil {
-		log.Panic(err)
+		return err
 	}
 	conn.SetReadBuffer(maxDataGramSize)
 
 	coll.ifaceToConn[iface] = conn
+	coll.addConnection(conn)
+	return nil
+}
+
+// addConnection starts the receiver of the socket, the caller has to hold the lock if the collector is running
+func (coll *Collector) addConnection(conn *net.UDPConn) {
 	coll.connections = append(coll.connections, conn)
+	coll.receivers.Add(1)
+	go func() {
+		defer coll.receivers.Done()
+		coll.receiver(conn)
+	}()
+}
+
+// SetInterfaces opens sockets on new interfaces and closes those of removed interfaces
+func (coll *Collector) SetInterfaces(ifaces []string) error {
+	coll.mutex.Lock()
+	defer coll.mutex.Unlock()
 
-	// Start receiver
-	go coll.receiver(conn)
+	wanted := make(map[string]bool, len(ifaces))
+	for _, iface := range ifaces {
+		wanted[iface] = true
+	}
+	for iface, conn := range coll.ifaceToConn {
+		if wanted[iface] {
+			continue
+		}
+		log.Println("closing socket on", iface)
+		conn.Close()
+		delete(coll.ifaceToConn, iface)
+		for i, c := range coll.connections {
+			if c == conn {
+				coll.connections = append(coll.connections[:i], coll.connections[i+1:]...)
+				break
+			}
+		}
+	}
+	for _, iface := range ifaces {
+		if _, found := coll.ifaceToConn[iface]; found {
+			continue
+		}
+		log.Println("opening socket on", iface)
+		if err := coll.listenUDP(iface); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conn returns the socket of the interface or nil
+func (coll *Collector) conn(iface string) *net.UDPConn {
+	coll.mutex.RLock()
+	defer coll.mutex.RUnlock()
+	return coll.ifaceToConn[iface]
+}
+
+// SetSites changes the sites of the global statistics
+func (coll *Collector) SetSites(sites []string) {
+	coll.mutex.Lock()
+	coll.sites = sites
+	coll.mutex.Unlock()
+}
+
+// SetDomains changes the domains of the global statistics
+func (coll *Collector) SetDomains(domains []string) {
+	coll.mutex.Lock()
+	coll.domains = domains
+	coll.mutex.Unlock()
 }
 
 // Returns the first link local unicast address for the given interface name
@@ -102,6 +182,27 @@ func getLinkLocalAddr(ifname string) (net.IP, error) {
 	return nil, fmt.Errorf("unable to find link local unicast address for %s", ifname)
 }
 
+// SetUnicastConfig changes the scheduling of unicast requests,
+// it is used from the next collection cycle on
+func (coll *Collector) SetUnicastConfig(config runtime.UnicastConfig) {
+	coll.mutex.Lock()
+	coll.unicast = config
+	coll.mutex.Unlock()
+}
+
+// unicastConfig returns the current scheduling of unicast requests
+func (coll *Collector) unicastConfig() runtime.UnicastConfig {
+	coll.mutex.RLock()
+	defer coll.mutex.RUnlock()
+	return coll.unicast
+}
+
+// SetCapture writes all received responses to the capture,
+// it has to be called before Start
+func (coll *Collector) SetCapture(capture *Capture) {
+	coll.capture = capture
+}
+
 // Start Collector
 func (coll *Collector) Start(interval time.Duration) {
 	if coll.interval != 0 {
@@ -121,23 +222,65 @@ func (coll *Collector) Start(interval time.Duration) {
 // Close Collector
 func (coll *Collector) Close() {
 	close(coll.stop)
+	coll.mutex.Lock()
 	for _, conn := range coll.connections {
 		conn.Close()
 	}
+	coll.mutex.Unlock()
+	// the queue is closed after the receivers of the closed sockets returned
+	coll.receivers.Wait()
 	close(coll.queue)
+	<-coll.done
+	if coll.capture != nil {
+		coll.capture.Close()
+	}
+}
+
+// Replay feeds the responses of a capture file through the parser
+// and closes the collector afterwards.
+// If realtime is set, the responses are delayed like they were received.
+func (coll *Collector) Replay(path string, realtime bool) error {
+	var last time.Time
+	err := ReadCapture(path, func(res *Response) {
+		if realtime && !last.IsZero() && res.Received.After(last) {
+			time.Sleep(res.Received.Sub(last))
+		}
+		last = res.Received
+		coll.queue <- res
+	})
+
+	// wait until all responses are processed
+	coll.Close()
+
+	if coll.db != nil {
+		coll.saveGlobalStats()
+	}
+	return err
 }
 
 func (coll *Collector) sendOnce() {
 	now := jsontime.Now()
+	coll.nodes.NewCycle()
+	coll.expireSplit(now.GetTime())
 	coll.sendMulticast()
 
 	// Wait for the multicast responses to be processed and send unicasts
-	time.Sleep(coll.interval / 2)
+	delay := coll.unicastConfig().Delay.Duration
+	if delay <= 0 {
+		delay = coll.interval / 2
+	}
+	select {
+	case <-coll.stop:
+		return
+	case <-time.After(delay):
+	}
 	coll.sendUnicasts(now)
 }
 
 func (coll *Collector) sendMulticast() {
 	log.Println("sending multicasts")
+	coll.mutex.RLock()
+	defer coll.mutex.RUnlock()
 	for _, conn := range coll.connections {
 		coll.sendPacket(conn, multiCastGroup)
 	}
@@ -148,39 +291,124 @@ func (coll *Collector) sendUnicasts(seenBefore jsontime.Time) {
 	seenAfter := seenBefore.Add(-time.Minute * 10)
 
 	// Select online nodes that has not been seen recently
-	nodes := coll.nodes.Select(func(n *runtime.Node) bool {
+	selected := coll.nodes.Select(func(n *runtime.Node) bool {
 		return n.Lastseen.After(seenAfter) && n.Lastseen.Before(seenBefore) && n.Address != nil
 	})
 
+	// Work on copies, the selected nodes are updated by the parser
+	nodes := make([]*runtime.Node, len(selected))
+	origins := make(map[*runtime.Node]*runtime.Node, len(selected))
+	coll.nodes.RLock()
+	for i, node := range selected {
+		nodes[i] = node.Copy()
+		origins[nodes[i]] = node
+	}
+	coll.nodes.RUnlock()
+
 	// Send unicast packets
 	log.Printf("sending unicast to %d nodes", len(nodes))
+	config := coll.unicastConfig()
+	scheduler := &unicastScheduler{
+		config: config,
+		stop:   coll.stop,
+		send:   coll.sendUnicast,
+		answered: func(node *runtime.Node) bool {
+			coll.nodes.RLock()
+			defer coll.nodes.RUnlock()
+			return !origins[node].Lastseen.Before(seenBefore)
+		},
+		retries: func(node *runtime.Node) int {
+			var nodeID string
+			if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+				nodeID = nodeinfo.NodeID
+			}
+			if policy := coll.nodes.Policy(nodeID, node); policy != nil && policy.UnicastRetries != nil {
+				return *policy.UnicastRetries
+			}
+			return config.Retries
+		},
+	}
+	unanswered := scheduler.run(nodes)
+	log.Printf("%d of %d unicast nodes answered", len(nodes)-len(unanswered), len(nodes))
+
+	missing := make(map[*runtime.Node]bool)
+	for _, node := range unanswered {
+		missing[node] = true
+	}
 	for _, node := range nodes {
-		conn := coll.ifaceToConn[node.Address.Zone]
-		if conn == nil {
-			log.Printf("unable to find connection for %s", node.Address.Zone)
-			continue
-		}
+		coll.metrics.update(node.Address.Zone, func(stats *runtime.InterfaceStats) {
+			stats.UnicastTargets++
+			if !missing[node] {
+				stats.UnicastAnswered++
+			}
+		})
+	}
+}
+
+// sendUnicast sends a request to the last known address of the node
+func (coll *Collector) sendUnicast(node *runtime.Node) bool {
+	conn := coll.conn(node.Address.Zone)
+	if conn == nil {
+		log.Printf("unable to find connection for %s", node.Address.Zone)
+		return false
+	}
+	if coll.isSplit(node.Address) {
+		coll.sendSplit(conn, node.Address.IP)
+	} else {
 		coll.sendPacket(conn, node.Address.IP)
-		time.Sleep(10 * time.Millisecond)
 	}
+	return true
+}
+
+// Query sends a unicast request to the last known address of the node
+func (coll *Collector) Query(nodeID string) error {
+	node := coll.nodes.Get(nodeID)
+	if node == nil {
+		return runtime.ErrNodeNotFound
+	}
+	if node.Address == nil {
+		return fmt.Errorf("address of node %s is unknown", nodeID)
+	}
+	if !coll.sendUnicast(node) {
+		return fmt.Errorf("unable to find connection for %s", node.Address.Zone)
+	}
+	return nil
 }
 
 // SendPacket sends a UDP request to the given unicast or multicast address on the first UDP socket
 func (coll *Collector) SendPacket(destination net.IP) {
-	coll.sendPacket(coll.connections[0], destination)
+	coll.mutex.RLock()
+	conn := coll.connections[0]
+	coll.mutex.RUnlock()
+	coll.sendPacket(conn, destination)
 }
 
 // sendPacket sends a UDP request to the given unicast or multicast address on the given UDP socket
 func (coll *Collector) sendPacket(conn *net.UDPConn, destination net.IP) {
+	coll.sendRequest(conn, destination, "GET "+strings.Join(providers, " "))
+}
+
+// sendSplit sends a UDP request per provider to the given unicast address,
+// for nodes whose response does not fit into one datagram
+func (coll *Collector) sendSplit(conn *net.UDPConn, destination net.IP) {
+	for _, provider := range providers {
+		coll.sendRequest(conn, destination, "GET "+provider)
+	}
+}
+
+// sendRequest sends the request to the given address on the given UDP socket
+func (coll *Collector) sendRequest(conn *net.UDPConn, destination net.IP, request string) {
 	addr := net.UDPAddr{
 		IP:   destination,
 		Port: port,
 		Zone: conn.LocalAddr().(*net.UDPAddr).Zone,
 	}
 
-	if _, err := conn.WriteToUDP([]byte("GET nodeinfo statistics neighbours"), &addr); err != nil {
+	if _, err := conn.WriteToUDP([]byte(request), &addr); err != nil {
 		log.Println("WriteToUDP failed:", err)
+		return
 	}
+	coll.metrics.request(&addr, time.Now())
 }
 
 // send packets continously
@@ -199,27 +427,82 @@ func (coll *Collector) sender() {
 }
 
 func (coll *Collector) parser() {
+	defer close(coll.done)
 	for obj := range coll.queue {
-		if data, err := obj.parse(); err != nil {
+		if data, err := obj.parse(); isTruncated(err) {
+			coll.metrics.update(obj.Address.Zone, func(stats *runtime.InterfaceStats) {
+				stats.Truncated++
+			})
+			log.Println("truncated response from", obj.Address.String(), "- requesting providers separately")
+			coll.setSplit(obj.Address, time.Now())
+			if conn := coll.conn(obj.Address.Zone); conn != nil {
+				coll.sendSplit(conn, obj.Address.IP)
+			}
+		} else if err != nil {
+			coll.metrics.update(obj.Address.Zone, func(stats *runtime.InterfaceStats) {
+				stats.ParseErrors++
+			})
 			log.Println("unable to decode response from", obj.Address.String(), err, "\n", string(obj.Raw))
 		} else {
+			if data.NodeInfo != nil && data.Statistics != nil && data.Neighbours != nil {
+				coll.clearSplit(obj.Address)
+			}
 			coll.saveResponse(obj.Address, data)
 		}
 	}
 }
 
 func (res *Response) parse() (*data.ResponseData, error) {
-	// Deflate
-	deflater := flate.NewReader(bytes.NewReader(res.Raw))
-	defer deflater.Close()
+	// Decompress
+	reader, err := res.reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
 
 	// Unmarshal
 	rdata := &data.ResponseData{}
-	err := json.NewDecoder(deflater).Decode(rdata)
+	err = json.NewDecoder(reader).Decode(rdata)
 
 	return rdata, err
 }
 
+// splitExpiry is the time after a truncated response, after which the providers are requested at once again
+const splitExpiry = 10 * time.Minute
+
+// isSplit returns whether the providers have to be requested separately
+func (coll *Collector) isSplit(addr *net.UDPAddr) bool {
+	coll.splitMutex.Lock()
+	defer coll.splitMutex.Unlock()
+	_, ok := coll.split[addr.IP.String()]
+	return ok
+}
+
+// setSplit remembers to request the providers separately
+func (coll *Collector) setSplit(addr *net.UDPAddr, now time.Time) {
+	coll.splitMutex.Lock()
+	coll.split[addr.IP.String()] = now
+	coll.splitMutex.Unlock()
+}
+
+// clearSplit requests the providers at once again, e.g. after a complete response
+func (coll *Collector) clearSplit(addr *net.UDPAddr) {
+	coll.splitMutex.Lock()
+	delete(coll.split, addr.IP.String())
+	coll.splitMutex.Unlock()
+}
+
+// expireSplit clears the addresses, which did not send a truncated response since the expiry
+func (coll *Collector) expireSplit(now time.Time) {
+	coll.splitMutex.Lock()
+	for addr, truncated := range coll.split {
+		if now.Sub(truncated) >= splitExpiry {
+			delete(coll.split, addr)
+		}
+	}
+	coll.splitMutex.Unlock()
+}
+
 func (coll *Collector) saveResponse(addr *net.UDPAddr, res *data.ResponseData) {
 	// Search for NodeID
 	var nodeID string
@@ -233,6 +516,9 @@ func (coll *Collector) saveResponse(addr *net.UDPAddr, res *data.ResponseData) {
 
 	// Check length of nodeID
 	if len(nodeID) != 12 {
+		coll.metrics.update(addr.Zone, func(stats *runtime.InterfaceStats) {
+			stats.InvalidNodeID++
+		})
 		log.Printf("invalid NodeID '%s' from %s", nodeID, addr.String())
 		return
 	}
@@ -250,19 +536,20 @@ func (coll *Collector) saveResponse(addr *net.UDPAddr, res *data.ResponseData) {
 
 	// Process the data and update IP address
 	node := coll.nodes.Update(nodeID, res)
-	node.Address = addr
+	coll.nodes.SetAddress(nodeID, addr)
 
 	// Store statistics in database
 	if db := coll.db; db != nil {
-		db.InsertNode(node)
+		if res.Statistics != nil {
+			db.InsertNode(node)
+		}
 
 		// Store link data
-		if neighbours := node.Neighbours; neighbours != nil {
+		if neighbours := res.Neighbours; neighbours != nil {
 			coll.nodes.RLock()
-			for _, link := range coll.nodes.NodeLinks(node) {
-				db.InsertLink(&link, node.Lastseen.GetTime())
-			}
+			links := coll.nodes.NodeLinks(node)
 			coll.nodes.RUnlock()
+			database.InsertNodeLinks(db, node, links, node.Lastseen.GetTime())
 		}
 	}
 }
@@ -277,12 +564,30 @@ func (coll *Collector) receiver(conn *net.UDPConn) {
 			return
 		}
 
+		now := time.Now()
+		coll.metrics.received(src, now)
+
 		raw := make([]byte, n)
 		copy(raw, buf)
 
-		coll.queue <- &Response{
-			Address: src,
-			Raw:     raw,
+		res := &Response{
+			Address:  src,
+			Raw:      raw,
+			Received: now,
+		}
+		if coll.capture != nil {
+			if err := coll.capture.Write(res); err != nil {
+				log.Println("unable to capture response:", err)
+			}
+		}
+
+		select {
+		case coll.queue <- res:
+		default:
+			coll.metrics.update(src.Zone, func(stats *runtime.InterfaceStats) {
+				stats.QueueOverflow++
+			})
+			log.Println("queue is full, dropped response from", src.String())
 		}
 	}
 }
@@ -296,15 +601,26 @@ func (coll *Collector) globalStatsWorker() {
 			return
 		case <-ticker.C:
 			coll.saveGlobalStats()
+			coll.db.InsertCollectorStats(coll.Stats(), time.Now())
 		}
 	}
 }
 
+// Stats returns the self-metrics of the collector per interface
+func (coll *Collector) Stats() runtime.CollectorStats {
+	return coll.metrics.snapshot()
+}
+
 // saves global statistics
 func (coll *Collector) saveGlobalStats() {
-	stats := runtime.NewGlobalStats(coll.nodes, coll.sites)
-
-	for site, stat := range stats {
-		coll.db.InsertGlobals(stat, time.Now(), site)
+	coll.mutex.RLock()
+	sites, domains := coll.sites, coll.domains
+	coll.mutex.RUnlock()
+	stats := runtime.NewGlobalStats(coll.nodes.Snapshot(), sites, domains)
+
+	for site, siteStats := range stats {
+		for domain, stat := range siteStats {
+			coll.db.InsertGlobals(stat, time.Now(), site, domain)
+		}
 	}
 }
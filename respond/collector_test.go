@@ -2,6 +2,7 @@ package respond
 
 import (
 	"io/ioutil"
+	"net"
 	"testing"
 	"time"
 
@@ -20,6 +21,24 @@ func TestCollector(t *testing.T) {
 	collector.Close()
 }
 
+func TestCollectorReload(t *testing.T) {
+	assert := assert.New(t)
+	nodes := runtime.NewNodes(&runtime.Config{})
+
+	collector := NewCollector(nil, nodes, []string{SITE_TEST}, []string{}, 10001)
+	assert.Error(collector.SetInterfaces([]string{"yanic-missing0"}))
+	assert.Len(collector.connections, 0)
+
+	collector.SetSites([]string{"ffhb"})
+	collector.SetUnicastConfig(runtime.UnicastConfig{Retries: 2})
+	assert.Equal([]string{"ffhb"}, collector.sites)
+	assert.Equal(2, collector.unicastConfig().Retries)
+
+	collector.Start(time.Millisecond)
+	time.Sleep(time.Millisecond * 10)
+	collector.Close()
+}
+
 func TestParse(t *testing.T) {
 	assert := assert.New(t)
 
@@ -38,3 +57,42 @@ func TestParse(t *testing.T) {
 
 	assert.Equal("f81a67a5e9c1", data.NodeInfo.NodeID)
 }
+
+func TestCollectorCloseWhileReceiving(t *testing.T) {
+	nodes := runtime.NewNodes(&runtime.Config{})
+	collector := NewCollector(nil, nodes, []string{SITE_TEST}, []string{}, 0)
+
+	// a receiver, which sends its response after the sockets were closed
+	sent := make(chan struct{})
+	collector.receivers.Add(1)
+	go func() {
+		defer close(sent)
+		defer collector.receivers.Done()
+		time.Sleep(time.Millisecond * 20)
+		collector.queue <- &Response{Address: &net.UDPAddr{}, Raw: []byte("invalid")}
+	}()
+	collector.Close()
+	<-sent
+}
+
+func TestCollectorSplit(t *testing.T) {
+	assert := assert.New(t)
+	collector := NewCollector(nil, runtime.NewNodes(&runtime.Config{}), []string{SITE_TEST}, []string{}, 0)
+	addr := &net.UDPAddr{IP: net.ParseIP("fe80::1"), Zone: "eth0"}
+	now := time.Now()
+
+	collector.setSplit(addr, now)
+	assert.True(collector.isSplit(addr))
+	collector.expireSplit(now.Add(time.Minute))
+	assert.True(collector.isSplit(addr))
+
+	// after a complete response
+	collector.clearSplit(addr)
+	assert.False(collector.isSplit(addr))
+
+	// without a truncated response since the expiry
+	collector.setSplit(addr, now)
+	collector.expireSplit(now.Add(splitExpiry))
+	assert.False(collector.isSplit(addr))
+	assert.Len(collector.split, 0)
+}
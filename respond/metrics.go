@@ -0,0 +1,76 @@
+package respond
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// metrics of the collector per interface
+type metrics struct {
+	stats     runtime.CollectorStats
+	requested map[string]time.Time // last multicast request time, indexed by interface
+	unicasts  map[string]time.Time // unicast request time, indexed by address
+	sync.Mutex
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		stats:     make(runtime.CollectorStats),
+		requested: make(map[string]time.Time),
+		unicasts:  make(map[string]time.Time),
+	}
+}
+
+// update the stats of an interface
+func (m *metrics) update(iface string, f func(*runtime.InterfaceStats)) {
+	m.Lock()
+	f(m.stats.Get(iface))
+	m.Unlock()
+}
+
+// request remembers the time of a request to calculate the latency of the response
+func (m *metrics) request(addr *net.UDPAddr, t time.Time) {
+	m.Lock()
+	if addr.IP.IsMulticast() {
+		m.requested[addr.Zone] = t
+		// the unicasts of the previous collect interval, which were not answered
+		for key, requested := range m.unicasts {
+			if requested.Before(t) {
+				delete(m.unicasts, key)
+			}
+		}
+	} else {
+		m.unicasts[addr.String()] = t
+	}
+	m.Unlock()
+}
+
+// received counts a datagram and its latency
+func (m *metrics) received(addr *net.UDPAddr, t time.Time) {
+	m.Lock()
+	defer m.Unlock()
+
+	stats := m.stats.Get(addr.Zone)
+	stats.Received++
+
+	// unicast request or the last multicast on this interface
+	requested, ok := m.unicasts[addr.String()]
+	if ok {
+		delete(m.unicasts, addr.String())
+	} else {
+		requested, ok = m.requested[addr.Zone]
+	}
+	if ok && !t.Before(requested) {
+		stats.AddLatency(t.Sub(requested))
+	}
+}
+
+// snapshot returns a copy of the current stats
+func (m *metrics) snapshot() runtime.CollectorStats {
+	m.Lock()
+	defer m.Unlock()
+	return m.stats.Copy()
+}
@@ -0,0 +1,54 @@
+package respond
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	m := newMetrics()
+	now := time.Now()
+
+	multicast := &net.UDPAddr{IP: multiCastGroup, Zone: "eth0"}
+	unicast := &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001, Zone: "eth0"}
+
+	// response without request
+	m.received(unicast, now)
+	stats := m.snapshot()
+	assert.EqualValues(1, stats["eth0"].Received)
+	assert.EqualValues(0, stats["eth0"].Responses)
+
+	// response to the multicast
+	m.request(multicast, now)
+	m.received(unicast, now.Add(time.Second))
+
+	// response to the unicast
+	m.request(unicast, now.Add(time.Second))
+	m.received(unicast, now.Add(4*time.Second))
+
+	m.update("eth0", func(stats *runtime.InterfaceStats) {
+		stats.ParseErrors++
+	})
+
+	stats = m.snapshot()
+	assert.EqualValues(3, stats["eth0"].Received)
+	assert.EqualValues(2, stats["eth0"].Responses)
+	assert.EqualValues(1, stats["eth0"].ParseErrors)
+	assert.Equal(2.0, stats["eth0"].LatencyAvgS)
+	assert.Equal(3.0, stats["eth0"].LatencyMaxS)
+
+	// unanswered unicasts are dropped on the next multicast
+	m.request(unicast, now.Add(5*time.Second))
+	assert.Len(m.unicasts, 1)
+	m.request(multicast, now.Add(time.Minute))
+	assert.Len(m.unicasts, 0)
+
+	// snapshot is a copy
+	stats["eth0"].Received = 0
+	assert.EqualValues(3, m.snapshot()["eth0"].Received)
+}
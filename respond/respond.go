@@ -1,22 +1,57 @@
 package respond
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
 	"net"
+	"time"
 )
 
 // default multicast group used by announced
 var multiCastGroup = net.ParseIP("ff02:0:0:0:0:0:2:1001")
 
+// providers requested from respondd
+var providers = []string{"nodeinfo", "statistics", "neighbours"}
+
 const (
 	// default udp port used by announced
 	port = 1001
 
-	// maximum receivable size
-	maxDataGramSize = 8192
+	// maximum receivable size (maximum size of an UDP datagram)
+	maxDataGramSize = 65535
 )
 
 // Response of the respond request
 type Response struct {
-	Address *net.UDPAddr
-	Raw     []byte
+	Address  *net.UDPAddr
+	Raw      []byte
+	Received time.Time
+}
+
+// reader detects the compression of the response:
+// plain JSON, gzip, zlib or raw deflate (the default of respondd)
+func (res *Response) reader() (io.ReadCloser, error) {
+	raw := res.Raw
+	trimmed := bytes.TrimLeft(raw, " \t\r\n")
+
+	switch {
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		return ioutil.NopCloser(bytes.NewReader(trimmed)), nil
+	case len(raw) >= 2 && raw[0] == 0x1f && raw[1] == 0x8b:
+		return gzip.NewReader(bytes.NewReader(raw))
+	case len(raw) >= 2 && raw[0]&0x0f == 8 && (uint16(raw[0])<<8|uint16(raw[1]))%31 == 0:
+		if reader, err := zlib.NewReader(bytes.NewReader(raw)); err == nil {
+			return reader, nil
+		}
+	}
+	return flate.NewReader(bytes.NewReader(raw)), nil
+}
+
+// isTruncated returns whether the error is caused by an incomplete datagram
+func isTruncated(err error) bool {
+	return err == io.ErrUnexpectedEOF
 }
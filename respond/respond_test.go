@@ -0,0 +1,59 @@
+package respond
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testResponse = `{"nodeinfo":{"node_id":"f81a67a5e9c1"}}`
+
+func TestParseFormats(t *testing.T) {
+	assert := assert.New(t)
+
+	var gzipped, zlibbed bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	w.Write([]byte(testResponse))
+	w.Close()
+	z := zlib.NewWriter(&zlibbed)
+	z.Write([]byte(testResponse))
+	z.Close()
+
+	for name, raw := range map[string][]byte{
+		"plain": []byte(testResponse),
+		"space": []byte("\n " + testResponse),
+		"gzip":  gzipped.Bytes(),
+		"zlib":  zlibbed.Bytes(),
+	} {
+		res := &Response{Raw: raw}
+		data, err := res.parse()
+		assert.NoError(err, name)
+		assert.Equal("f81a67a5e9c1", data.NodeInfo.NodeID, name)
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	assert := assert.New(t)
+
+	compressed, err := ioutil.ReadFile("testdata/nodeinfo.flated")
+	assert.NoError(err)
+
+	res := &Response{Raw: compressed[:len(compressed)/2]}
+	_, err = res.parse()
+	assert.Error(err)
+	assert.True(isTruncated(err))
+
+	res = &Response{Raw: []byte(testResponse[:20])}
+	_, err = res.parse()
+	assert.True(isTruncated(err))
+
+	// not truncated but invalid
+	res = &Response{Raw: []byte("{]")}
+	_, err = res.parse()
+	assert.Error(err)
+	assert.False(isTruncated(err))
+}
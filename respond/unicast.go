@@ -0,0 +1,164 @@
+package respond
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+const (
+	defaultUnicastRate      = 100
+	defaultUnicastRetryWait = time.Second
+)
+
+// unicastScheduler sends rate-limited requests to a list of nodes
+// and retries the nodes, which did not answer
+type unicastScheduler struct {
+	config   runtime.UnicastConfig
+	send     func(*runtime.Node) bool // sends a request, returns false if the node is not reachable
+	answered func(*runtime.Node) bool // returns whether the node has answered in this cycle
+	retries  func(*runtime.Node) int  // returns the number of retries of the node (default: config.Retries)
+	stop     chan interface{}
+}
+
+// run sends requests to all nodes and returns the nodes which did not answer
+func (s *unicastScheduler) run(nodes []*runtime.Node) []*runtime.Node {
+	pending := nodes
+	var unanswered []*runtime.Node
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if !s.sendAll(pending) {
+			break
+		}
+
+		// Wait for the responses
+		select {
+		case <-s.stop:
+			return append(unanswered, pending...)
+		case <-time.After(s.retryWait()):
+		}
+
+		var retry []*runtime.Node
+		for _, node := range pending {
+			if s.answered(node) {
+				continue
+			}
+			if attempt < s.nodeRetries(node) {
+				retry = append(retry, node)
+			} else {
+				unanswered = append(unanswered, node)
+			}
+		}
+		pending = retry
+	}
+
+	return append(unanswered, pending...)
+}
+
+// sendAll sends one request to each node.
+// Returns false if the scheduler was stopped during sending.
+func (s *unicastScheduler) sendAll(nodes []*runtime.Node) bool {
+	limiter := time.NewTicker(time.Second / time.Duration(s.rate()))
+	defer limiter.Stop()
+
+	// Group nodes by interface in random order
+	queues := make(map[string]chan *runtime.Node)
+	for _, i := range rand.Perm(len(nodes)) {
+		node := nodes[i]
+		zone := node.Address.Zone
+		if queues[zone] == nil {
+			queues[zone] = make(chan *runtime.Node, len(nodes))
+		}
+		queues[zone] <- node
+	}
+
+	stopped := false
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, queue := range queues {
+		close(queue)
+		for i := 0; i < s.concurrency(); i++ {
+			wg.Add(1)
+			go func(queue chan *runtime.Node) {
+				defer wg.Done()
+				if !s.wait(s.jitter()) {
+					mutex.Lock()
+					stopped = true
+					mutex.Unlock()
+					return
+				}
+				for node := range queue {
+					if !s.wait(limiter.C) {
+						mutex.Lock()
+						stopped = true
+						mutex.Unlock()
+						return
+					}
+					s.send(node)
+				}
+			}(queue)
+		}
+	}
+	wg.Wait()
+
+	return !stopped
+}
+
+// wait until the channel fires.
+// Returns false if the scheduler was stopped.
+func (s *unicastScheduler) wait(c <-chan time.Time) bool {
+	select {
+	case <-s.stop:
+		return false
+	default:
+	}
+	select {
+	case <-s.stop:
+		return false
+	case <-c:
+		return true
+	}
+}
+
+// jitter returns a channel, which fires after a random delay
+// to avoid synchronized bursts of the senders
+func (s *unicastScheduler) jitter() <-chan time.Time {
+	if jitter := s.config.Jitter.Duration; jitter > 0 {
+		return time.After(time.Duration(rand.Int63n(int64(jitter))))
+	}
+	return time.After(0)
+}
+
+func (s *unicastScheduler) nodeRetries(node *runtime.Node) int {
+	if s.retries != nil {
+		return s.retries(node)
+	}
+	return s.config.Retries
+}
+
+func (s *unicastScheduler) rate() int {
+	if s.config.Rate > runtime.MaxUnicastRate {
+		return runtime.MaxUnicastRate
+	}
+	if s.config.Rate > 0 {
+		return s.config.Rate
+	}
+	return defaultUnicastRate
+}
+
+func (s *unicastScheduler) concurrency() int {
+	if s.config.Concurrency > 0 {
+		return s.config.Concurrency
+	}
+	return 1
+}
+
+func (s *unicastScheduler) retryWait() time.Duration {
+	if wait := s.config.RetryWait.Duration; wait > 0 {
+		return wait
+	}
+	return defaultUnicastRetryWait
+}
@@ -0,0 +1,108 @@
+package respond
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnicastScheduler(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := []*runtime.Node{
+		{Address: &net.UDPAddr{Zone: "eth0"}},
+		{Address: &net.UDPAddr{Zone: "eth0"}},
+		{Address: &net.UDPAddr{Zone: "eth1"}},
+	}
+
+	var mutex sync.Mutex
+	sent := make(map[*runtime.Node]int)
+
+	scheduler := &unicastScheduler{
+		config: runtime.UnicastConfig{
+			Rate:        1000,
+			Concurrency: 2,
+			Retries:     2,
+			RetryWait:   runtime.Duration{Duration: time.Millisecond},
+		},
+		stop: make(chan interface{}),
+		send: func(node *runtime.Node) bool {
+			mutex.Lock()
+			sent[node]++
+			mutex.Unlock()
+			return true
+		},
+		answered: func(node *runtime.Node) bool {
+			// the first node never answers
+			return node != nodes[0]
+		},
+	}
+
+	assert.Equal([]*runtime.Node{nodes[0]}, scheduler.run(nodes))
+	assert.Equal(3, sent[nodes[0]], "first node should be retried")
+	assert.Equal(1, sent[nodes[1]])
+	assert.Equal(1, sent[nodes[2]])
+
+	// stopped scheduler does not send
+	close(scheduler.stop)
+	sent = make(map[*runtime.Node]int)
+	assert.Len(scheduler.run(nodes), 3)
+	assert.Len(sent, 0)
+}
+
+func TestUnicastSchedulerRetries(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := []*runtime.Node{
+		{Address: &net.UDPAddr{Zone: "eth0"}},
+		{Address: &net.UDPAddr{Zone: "eth0"}},
+	}
+
+	var mutex sync.Mutex
+	sent := make(map[*runtime.Node]int)
+
+	scheduler := &unicastScheduler{
+		config: runtime.UnicastConfig{
+			Rate:      1000,
+			Retries:   1,
+			RetryWait: runtime.Duration{Duration: time.Millisecond},
+		},
+		stop: make(chan interface{}),
+		send: func(node *runtime.Node) bool {
+			mutex.Lock()
+			sent[node]++
+			mutex.Unlock()
+			return true
+		},
+		answered: func(node *runtime.Node) bool {
+			return false
+		},
+		retries: func(node *runtime.Node) int {
+			if node == nodes[0] {
+				return 3
+			}
+			return 0
+		},
+	}
+
+	assert.Len(scheduler.run(nodes), 2)
+	assert.Equal(4, sent[nodes[0]])
+	assert.Equal(1, sent[nodes[1]])
+}
+
+func TestUnicastSchedulerDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	scheduler := &unicastScheduler{}
+	assert.Equal(defaultUnicastRate, scheduler.rate())
+	assert.Equal(1, scheduler.concurrency())
+	assert.Equal(defaultUnicastRetryWait, scheduler.retryWait())
+
+	// the interval of the packets is at least 1ns
+	scheduler.config.Rate = 2000000000
+	assert.Equal(runtime.MaxUnicastRate, scheduler.rate())
+}
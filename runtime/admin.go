@@ -0,0 +1,153 @@
+package runtime
+
+import (
+	"errors"
+	"log"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+// ErrNodeNotFound is returned by the management functions for unknown node IDs
+var ErrNodeNotFound = errors.New("node not found")
+
+// NodeOverrides are set by an administrator and replace the announced values
+type NodeOverrides struct {
+	Hostname string         `json:"hostname,omitempty"`
+	Owner    string         `json:"owner,omitempty"`
+	Location *data.Location `json:"location,omitempty"`
+	Note     string         `json:"note,omitempty"`
+}
+
+// Copy returns a deep copy of the overrides
+func (overrides *NodeOverrides) Copy() *NodeOverrides {
+	c := *overrides
+	if location := overrides.Location; location != nil {
+		l := *location
+		c.Location = &l
+	}
+	return &c
+}
+
+// IsEmpty returns whether no value is overridden
+func (overrides *NodeOverrides) IsEmpty() bool {
+	return *overrides == NodeOverrides{}
+}
+
+// Apply returns a copy of the nodeinfo with the overridden values
+func (overrides *NodeOverrides) Apply(nodeinfo *data.NodeInfo) *data.NodeInfo {
+	c := *nodeinfo
+	if overrides.Hostname != "" {
+		c.Hostname = overrides.Hostname
+	}
+	if overrides.Owner != "" {
+		c.Owner = &data.Owner{Contact: overrides.Owner}
+	}
+	if overrides.Location != nil {
+		c.Location = overrides.Location
+	}
+	return &c
+}
+
+// Get returns a copy of the node
+func (nodes *Nodes) Get(nodeID string) *Node {
+	nodes.RLock()
+	defer nodes.RUnlock()
+
+	if node := nodes.List[nodeID]; node != nil {
+		return node.Copy()
+	}
+	return nil
+}
+
+// Delete removes a node
+func (nodes *Nodes) Delete(nodeID string) error {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	if nodes.List[nodeID] == nil {
+		return ErrNodeNotFound
+	}
+	nodes.delete(nodeID)
+	log.Println("removed node", nodeID)
+	return nil
+}
+
+// Merge moves the history and the settings of a node to a new node ID,
+// e.g. after swapping the hardware, and removes the old node
+func (nodes *Nodes) Merge(oldNodeID, newNodeID string) error {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	oldNode := nodes.List[oldNodeID]
+	newNode := nodes.List[newNodeID]
+	if oldNode == nil || newNode == nil {
+		return ErrNodeNotFound
+	}
+	if oldNodeID == newNodeID {
+		return errors.New("unable to merge a node with itself")
+	}
+
+	if oldNode.Firstseen.Before(newNode.Firstseen) {
+		newNode.Firstseen = oldNode.Firstseen
+	}
+	if newNode.Overrides == nil {
+		newNode.Overrides = oldNode.Overrides
+	}
+	newNode.Maintenance = newNode.Maintenance || oldNode.Maintenance
+
+	nodes.delete(oldNodeID)
+	nodes.markChanged(newNodeID)
+	log.Printf("merged node %s into %s", oldNodeID, newNodeID)
+	return nil
+}
+
+// UpdateOverrides changes the overridden values of a node
+func (nodes *Nodes) UpdateOverrides(nodeID string, f func(*NodeOverrides) error) error {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	node := nodes.List[nodeID]
+	if node == nil {
+		return ErrNodeNotFound
+	}
+
+	// the overrides are shared with copies of the node
+	overrides := &NodeOverrides{}
+	if node.Overrides != nil {
+		overrides = node.Overrides.Copy()
+	}
+	if err := f(overrides); err != nil {
+		return err
+	}
+	if overrides.IsEmpty() {
+		overrides = nil
+	}
+	node.Overrides = overrides
+	nodes.markChanged(nodeID)
+	return nil
+}
+
+// SetMaintenance sets the maintenance flag of a node
+func (nodes *Nodes) SetMaintenance(nodeID string, maintenance bool) error {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	node := nodes.List[nodeID]
+	if node == nil {
+		return ErrNodeNotFound
+	}
+	node.Maintenance = maintenance
+	nodes.markChanged(nodeID)
+	return nil
+}
+
+// delete removes a node and its MAC addresses, the lock must be held
+func (nodes *Nodes) delete(nodeID string) {
+	delete(nodes.List, nodeID)
+	for mac, id := range nodes.ifaceToNodeID {
+		if id == nodeID {
+			delete(nodes.ifaceToNodeID, mac)
+		}
+	}
+	nodes.markChanged(nodeID)
+}
@@ -0,0 +1,78 @@
+package runtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+func TestNodeManagement(t *testing.T) {
+	assert := assert.New(t)
+	nodes := NewNodes(&Config{})
+
+	nodes.Update("000000000001", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{
+			NodeID:   "000000000001",
+			Hostname: "old",
+			Network:  data.Network{Mac: "00:00:00:00:00:01"},
+		},
+	})
+	nodes.Update("000000000002", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{NodeID: "000000000002", Hostname: "new"},
+	})
+	nodes.List["000000000001"].Firstseen = nodes.List["000000000001"].Firstseen.Add(-time.Hour)
+
+	// overrides
+	assert.Equal(ErrNodeNotFound, nodes.UpdateOverrides("unknown", nil))
+	err := nodes.UpdateOverrides("000000000001", func(overrides *NodeOverrides) error {
+		return json.Unmarshal([]byte(`{"hostname":"pinned","location":{"latitude":53}}`), overrides)
+	})
+	assert.NoError(err)
+	assert.Equal("old", nodes.Get("000000000001").Nodeinfo.Hostname, "stored data is not changed")
+
+	snapshot := nodes.Snapshot().List["000000000001"]
+	assert.Equal("pinned", snapshot.Nodeinfo.Hostname)
+	assert.Equal(53.0, snapshot.Nodeinfo.Location.Latitude)
+
+	// announced values do not replace overrides
+	node := nodes.Update("000000000001", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{NodeID: "000000000001", Hostname: "announced"},
+	})
+	assert.Equal("pinned", node.Nodeinfo.Hostname)
+
+	// maintenance
+	assert.Equal(ErrNodeNotFound, nodes.SetMaintenance("unknown", true))
+	assert.NoError(nodes.SetMaintenance("000000000001", true))
+	assert.True(nodes.Snapshot().List["000000000001"].Maintenance)
+
+	// merge
+	assert.Equal(ErrNodeNotFound, nodes.Merge("unknown", "000000000002"))
+	assert.Error(nodes.Merge("000000000002", "000000000002"))
+	firstseen := nodes.List["000000000001"].Firstseen
+	assert.NoError(nodes.Merge("000000000001", "000000000002"))
+	assert.Nil(nodes.Get("000000000001"))
+	assert.Equal("", nodes.GetNodeIDbyMAC("00:00:00:00:00:01"))
+	merged := nodes.Get("000000000002")
+	assert.Equal(firstseen, merged.Firstseen)
+	assert.True(merged.Maintenance)
+	assert.Equal("pinned", merged.Overrides.Hostname)
+
+	// removing all overrides
+	err = nodes.UpdateOverrides("000000000002", func(overrides *NodeOverrides) error {
+		*overrides = NodeOverrides{}
+		return nil
+	})
+	assert.NoError(err)
+	assert.Nil(nodes.Get("000000000002").Overrides)
+	assert.Equal("pinned", snapshot.Nodeinfo.Hostname, "snapshots are not changed")
+
+	// delete
+	assert.Equal(ErrNodeNotFound, nodes.Delete("000000000001"))
+	assert.NoError(nodes.Delete("000000000002"))
+	assert.Len(nodes.List, 0)
+	assert.Len(nodes.changed, 2)
+}
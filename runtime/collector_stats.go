@@ -0,0 +1,54 @@
+package runtime
+
+import "time"
+
+// CollectorStats self-metrics of the collector, indexed by interface
+type CollectorStats map[string]*InterfaceStats
+
+// InterfaceStats self-metrics of the collector on one interface
+type InterfaceStats struct {
+	Received        uint64 `json:"received"`         // received datagrams
+	ParseErrors     uint64 `json:"parse_errors"`     // datagrams which could not be decompressed or unmarshaled
+	Truncated       uint64 `json:"truncated"`        // datagrams which were incomplete
+	InvalidNodeID   uint64 `json:"invalid_node_id"`  // responses without a valid node ID
+	QueueOverflow   uint64 `json:"queue_overflow"`   // datagrams dropped due to a full queue
+	UnicastTargets  uint64 `json:"unicast_targets"`  // nodes requested by unicast
+	UnicastAnswered uint64 `json:"unicast_answered"` // nodes which answered a unicast
+
+	Responses   uint64        `json:"responses"`   // responses with a known request time
+	LatencySum  time.Duration `json:"-"`           // summed time between request and response
+	LatencyMax  time.Duration `json:"-"`           // longest time between request and response
+	LatencyAvgS float64       `json:"latency_avg"` // average time between request and response in seconds
+	LatencyMaxS float64       `json:"latency_max"` // longest time between request and response in seconds
+}
+
+// AddLatency adds the time between request and response
+func (s *InterfaceStats) AddLatency(latency time.Duration) {
+	s.Responses++
+	s.LatencySum += latency
+	if latency > s.LatencyMax {
+		s.LatencyMax = latency
+	}
+	s.LatencyAvgS = (s.LatencySum / time.Duration(s.Responses)).Seconds()
+	s.LatencyMaxS = s.LatencyMax.Seconds()
+}
+
+// Get returns the stats of the interface and creates it if necessary
+func (stats CollectorStats) Get(iface string) *InterfaceStats {
+	s := stats[iface]
+	if s == nil {
+		s = &InterfaceStats{}
+		stats[iface] = s
+	}
+	return s
+}
+
+// Copy returns a deep copy of the stats
+func (stats CollectorStats) Copy() CollectorStats {
+	result := make(CollectorStats, len(stats))
+	for iface, s := range stats {
+		c := *s
+		result[iface] = &c
+	}
+	return result
+}
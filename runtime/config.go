@@ -1,33 +1,42 @@
 package runtime
 
 import (
+	"bytes"
 	"io/ioutil"
 
 	"github.com/BurntSushi/toml"
 )
 
-//Config the config File of this daemon
+// Config the config File of this daemon
 type Config struct {
 	Respondd struct {
-		Enable          bool     `toml:"enable"`
-		Synchronize     Duration `toml:"synchronize"`
-		Interfaces      []string `toml:"interfaces"`
-		Sites           []string `toml:"sites"`
-		Port            int      `toml:"port"`
-		CollectInterval Duration `toml:"collect_interval"`
+		Enable          bool          `toml:"enable"`
+		Synchronize     Duration      `toml:"synchronize"`
+		Interfaces      []string      `toml:"interfaces"`
+		Sites           []string      `toml:"sites"`
+		Domains         []string      `toml:"domains"`
+		Port            int           `toml:"port"`
+		CollectInterval Duration      `toml:"collect_interval"`
+		CapturePath     string        `toml:"capture_path"` // Write all received responses to this file
+		Unicast         UnicastConfig `toml:"unicast"`
 	}
 	Webserver struct {
-		Enable  bool   `toml:"enable"`
-		Bind    string `toml:"bind"`
-		Webroot string `toml:"webroot"`
+		Enable     bool   `toml:"enable"`
+		Bind       string `toml:"bind"`
+		Webroot    string `toml:"webroot"`
+		AdminToken string `toml:"admin_token"` // Enables the node management API
 	}
 	Nodes struct {
-		Enable       bool     `toml:"enable"`
-		StatePath    string   `toml:"state_path"`
-		SaveInterval Duration `toml:"save_interval"` // Save nodes periodically
-		OfflineAfter Duration `toml:"offline_after"` // Set node to offline if not seen within this period
-		PruneAfter   Duration `toml:"prune_after"`   // Remove nodes after n days of inactivity
-		Output       map[string]interface{}
+		Enable           bool         `toml:"enable"`
+		StatePath        string       `toml:"state_path"`
+		StateCompression string       `toml:"state_compression"` // Compression of the state: gzip (default) or none
+		StateCompaction  Duration     `toml:"state_compaction"`  // Rewrite the whole state at least this often (default: 1h)
+		SaveInterval     Duration     `toml:"save_interval"`     // Save nodes periodically
+		OfflineAfter     Duration     `toml:"offline_after"`     // Set node to offline if not seen within this period
+		PruneAfter       Duration     `toml:"prune_after"`       // Remove nodes after n days of inactivity
+		OverlayPath      string       `toml:"overlay_path"`      // Static node data merged onto the collected nodes
+		Policies         []NodePolicy `toml:"policy"`            // Override settings for matching nodes
+		Output           map[string]interface{}
 	}
 	Meshviewer struct {
 		Version   int    `toml:"version"`
@@ -41,9 +50,31 @@ type Config struct {
 	}
 }
 
-// ReadConfigFile reads a config model from path of a yml file
+// UnicastConfig of the requests to nodes, which did not answer the multicast
+type UnicastConfig struct {
+	Delay       Duration `toml:"delay"`       // Wait after the multicast before sending unicasts (default: half of collect_interval)
+	Rate        int      `toml:"rate"`        // Global budget of packets per second (default: 100)
+	Concurrency int      `toml:"concurrency"` // Parallel senders per interface (default: 1)
+	Jitter      Duration `toml:"jitter"`      // Maximum random delay before each sender starts
+	Retries     int      `toml:"retries"`     // Resend requests to nodes, which still did not answer
+	RetryWait   Duration `toml:"retry_wait"`  // Wait for answers before retrying (default: 1s)
+}
+
+// DefaultConfig returns a config model with the defaults of the ConfigSections
+func DefaultConfig() *Config {
+	config := &Config{}
+	var buf bytes.Buffer
+	WriteConfigSections(&buf)
+	if _, err := toml.Decode(buf.String(), config); err != nil {
+		panic(err)
+	}
+	return config
+}
+
+// ReadConfigFile reads a config model from path of a yml file,
+// missing options are set to their defaults
 func ReadConfigFile(path string) (config *Config, err error) {
-	config = &Config{}
+	config = DefaultConfig()
 
 	file, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,244 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ConfigSection declares the options of a section of the configuration file
+type ConfigSection struct {
+	Name       string
+	Doc        string
+	Array      bool // array of tables, e.g. [[nodes.policy]]
+	Deprecated bool
+	Options    Options
+}
+
+// MaxUnicastRate is the highest rate of unicast requests, one packet per nanosecond
+const MaxUnicastRate = int(time.Second)
+
+// ConfigSections are the sections of Config
+var ConfigSections = []ConfigSection{
+	{
+		Name: "respondd",
+		Doc:  "Collects the data of the nodes by sending respondd requests",
+		Options: Options{
+			{Name: "enable", Type: OptionBool, Example: true, Doc: "Send respondd requests"},
+			{Name: "synchronize", Type: OptionDuration, Default: "1m", Doc: "Delay startup until a multiple of the period since zero time"},
+			{Name: "interfaces", Type: OptionList, Example: []interface{}{"br-ffhb"}, Doc: "Interfaces to send the requests on"},
+			{Name: "sites", Type: OptionList, Doc: "Site codes for the global statistics per site"},
+			{Name: "domains", Type: OptionList, Doc: "Domain codes for the global statistics per domain (Gluon multidomain)"},
+			{Name: "port", Type: OptionInt, Doc: "Local port to send the requests from (default: random)"},
+			{Name: "collect_interval", Type: OptionDuration, Default: "1m", Doc: "Interval of the multicast requests"},
+			{Name: "capture_path", Type: OptionString, Doc: "Write all received responses to this file, see yanic replay"},
+		},
+	},
+	{
+		Name: "respondd.unicast",
+		Doc:  "Unicast requests to the nodes, which did not answer the multicast request",
+		Options: Options{
+			{Name: "delay", Type: OptionDuration, Doc: "Wait after the multicast before sending unicasts (default: half of collect_interval)"},
+			{Name: "rate", Type: OptionInt, Default: int64(100), Doc: "Global budget of packets per second"},
+			{Name: "concurrency", Type: OptionInt, Default: int64(1), Doc: "Parallel senders per interface"},
+			{Name: "jitter", Type: OptionDuration, Doc: "Maximum random delay before each sender starts"},
+			{Name: "retries", Type: OptionInt, Default: int64(0), Doc: "Resend requests to nodes, which still did not answer"},
+			{Name: "retry_wait", Type: OptionDuration, Default: "1s", Doc: "Wait for answers before retrying"},
+		},
+	},
+	{
+		Name: "webserver",
+		Doc:  "A little build-in webserver, which statically serves a directory",
+		Options: Options{
+			{Name: "enable", Type: OptionBool, Default: false},
+			{Name: "bind", Type: OptionString, Default: "127.0.0.1:8080"},
+			{Name: "webroot", Type: OptionString, Example: "/var/www/html/meshviewer"},
+			{Name: "admin_token", Type: OptionString, Doc: "Enables the node management API at /api/admin/nodes/ used by yanic node"},
+		},
+	},
+	{
+		Name: "nodes",
+		Options: Options{
+			{Name: "enable", Type: OptionBool, Example: true},
+			{Name: "state_path", Type: OptionString, Example: "/var/lib/yanic/state.json", Doc: "Cache file of all data collected directly from respondd (default: not cached)"},
+			{Name: "state_compression", Type: OptionString, Default: StateCompressionGzip, Doc: "Compression of the cache file and its change log: gzip or none"},
+			{Name: "state_compaction", Type: OptionDuration, Default: "1h", Doc: "Rewrite the whole cache file at least this often"},
+			{Name: "save_interval", Type: OptionDuration, Default: "5s", Doc: "Export nodes and graph periodically"},
+			{Name: "offline_after", Type: OptionDuration, Default: "10m", Doc: "Set node to offline if not seen within this period"},
+			{Name: "prune_after", Type: OptionDuration, Default: "7d", Doc: "Remove nodes after this period of inactivity"},
+			{Name: "overlay_path", Type: OptionString, Doc: "Static data of nodes in a TOML or JSON (*.json) file"},
+		},
+	},
+	{
+		Name:  "nodes.policy",
+		Doc:   "Override settings for matching nodes, the first matching policy is used",
+		Array: true,
+		Options: Options{
+			{Name: "sites", Type: OptionList, Doc: "Site codes"},
+			{Name: "domains", Type: OptionList, Doc: "Domain codes"},
+			{Name: "models", Type: OptionList, Doc: "Hardware models"},
+			{Name: "node_ids", Type: OptionList, Doc: "Node IDs"},
+			{Name: "gateway", Type: OptionBool, Doc: "Whether the node is a gateway"},
+			{Name: "offline_after", Type: OptionDuration},
+			{Name: "prune_after", Type: OptionDuration},
+			{Name: "unicast_retries", Type: OptionInt},
+		},
+	},
+	{
+		Name:       "meshviewer",
+		Deprecated: true,
+		Doc:        "Deprecated: use [[nodes.output.meshviewer]]",
+		Options: Options{
+			{Name: "version", Type: OptionInt},
+			{Name: "nodes_path", Type: OptionString},
+			{Name: "graph_path", Type: OptionString},
+		},
+	},
+	{
+		Name: "database",
+		Options: Options{
+			{Name: "delete_interval", Type: OptionDuration, Default: "1h", Doc: "Delete stats of nodes periodically"},
+			{Name: "delete_after", Type: OptionDuration, Default: "7d", Doc: "Delete stats of nodes older than this"},
+		},
+	},
+}
+
+// WriteConfigSections writes the sections as annotated TOML
+func WriteConfigSections(w io.Writer) {
+	for _, section := range ConfigSections {
+		if section.Deprecated {
+			continue
+		}
+		if section.Doc != "" {
+			fmt.Fprintf(w, "# %s\n", section.Doc)
+		}
+		// arrays of tables are examples
+		if section.Array {
+			fmt.Fprintf(w, "#[[%s]]\n", section.Name)
+		} else {
+			fmt.Fprintf(w, "[%s]\n", section.Name)
+		}
+		section.Options.Write(w, section.Array)
+		fmt.Fprintln(w)
+	}
+}
+
+// Validate checks the values of the configuration, which are not checked by the decoder
+func (config *Config) Validate() (errs []error) {
+	invalid := func(key, msg string) {
+		errs = append(errs, &OptionError{Key: key, Msg: msg})
+	}
+
+	if config.Respondd.Enable {
+		if len(config.Respondd.Interfaces) == 0 {
+			invalid("respondd.interfaces", "no interfaces given")
+		}
+		if config.Respondd.CollectInterval.Duration <= 0 {
+			invalid("respondd.collect_interval", "has to be positive")
+		}
+		if config.Respondd.Unicast.Rate > MaxUnicastRate {
+			invalid("respondd.unicast.rate", fmt.Sprintf("has to be at most %d", MaxUnicastRate))
+		}
+	}
+	if config.Webserver.Enable && config.Webserver.Bind == "" {
+		invalid("webserver.bind", "no address given")
+	}
+	if config.Nodes.Enable && config.Nodes.SaveInterval.Duration <= 0 {
+		invalid("nodes.save_interval", "has to be positive")
+	}
+	switch config.Nodes.StateCompression {
+	case "", StateCompressionGzip, StateCompressionNone:
+	default:
+		invalid("nodes.state_compression", fmt.Sprintf("unknown compression %q", config.Nodes.StateCompression))
+	}
+	if config.Database.DeleteInterval.Duration < 0 || config.Database.DeleteAfter.Duration < 0 {
+		invalid("database", "durations have to be positive")
+	}
+	if interval := config.Database.DeleteInterval.Duration; interval > 0 && interval < time.Minute {
+		invalid("database.delete_interval", "has to be at least 1m")
+	}
+	return
+}
+
+var (
+	configArrayIndex = regexp.MustCompile(`\[\d+\]`)
+	configKeyParent  = regexp.MustCompile(`(\.[^.\[]+|\[\d+\])$`)
+)
+
+// ConfigLines returns the line numbers of the tables and keys of a TOML file,
+// e.g. "nodes.output.meshviewer[0].version".
+// The first occurrence of a key is also stored without the indexes of the arrays of tables.
+func ConfigLines(content []byte) map[string]int {
+	lines := make(map[string]int)
+	arrays := make(map[string]int) // number of tables per array of tables
+	prefix := ""
+
+	set := func(key string, line int) {
+		for _, k := range []string{key, configArrayIndex.ReplaceAllString(key, "")} {
+			if _, ok := lines[k]; !ok {
+				lines[k] = line
+			}
+		}
+	}
+	split := func(name string) []string {
+		parts := strings.Split(name, ".")
+		for i, part := range parts {
+			parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+		}
+		return parts
+	}
+	path := func(name string) string {
+		parts := split(name)
+		for i, part := range parts {
+			if count := arrays[strings.Join(parts[:i+1], ".")]; count > 0 {
+				parts[i] = fmt.Sprintf("%s[%d]", part, count-1)
+			}
+		}
+		return strings.Join(parts, ".")
+	}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		number := i + 1
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#':
+		case strings.HasPrefix(line, "[["):
+			name := strings.SplitN(line[2:], "]]", 2)[0]
+			arrays[strings.Join(split(name), ".")]++
+			prefix = path(name)
+			set(prefix, number)
+		case line[0] == '[':
+			prefix = path(strings.TrimSpace(strings.SplitN(line[1:], "]", 2)[0]))
+			set(prefix, number)
+		default:
+			if eq := strings.Index(line, "="); eq > 0 {
+				key := path(strings.TrimSpace(line[:eq]))
+				if prefix != "" {
+					key = prefix + "." + key
+				}
+				set(key, number)
+			}
+		}
+	}
+	return lines
+}
+
+// ConfigLine returns the line of the key or of its nearest parent, 0 if unknown
+func ConfigLine(lines map[string]int, key string) int {
+	for key != "" {
+		if line, ok := lines[key]; ok {
+			return line
+		}
+		if line, ok := lines[configArrayIndex.ReplaceAllString(key, "")]; ok {
+			return line
+		}
+		parent := configKeyParent.ReplaceAllString(key, "")
+		if parent == key {
+			break
+		}
+		key = parent
+	}
+	return 0
+}
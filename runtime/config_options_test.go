@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+)
+
+// every option of Config has to be declared in ConfigSections
+func TestConfigSections(t *testing.T) {
+	assert := assert.New(t)
+
+	sections := make(map[string]Options)
+	for _, section := range ConfigSections {
+		sections[section.Name] = section.Options
+	}
+
+	var walk func(prefix string, typ reflect.Type)
+	walk = func(prefix string, typ reflect.Type) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name := strings.ToLower(field.Name)
+			if tag := field.Tag.Get("toml"); tag != "" {
+				name = tag
+			}
+			key := strings.TrimPrefix(prefix+"."+name, ".")
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct {
+				fieldType = fieldType.Elem()
+			}
+			if _, ok := sections[key]; ok && fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(Duration{}) {
+				walk(key, fieldType)
+				continue
+			}
+			if prefix == "" || key == "nodes.output" || key == "database.connection" {
+				continue
+			}
+			assert.NotNil(sections[prefix].Get(name), "option %s is not declared", key)
+		}
+	}
+	walk("", reflect.TypeOf(Config{}))
+}
+
+func TestConfigSectionsWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	WriteConfigSections(&buf)
+	assert.NotContains(buf.String(), "[meshviewer]")
+
+	// the defaults are a valid configuration
+	config := &Config{}
+	md, err := toml.Decode(buf.String(), config)
+	assert.NoError(err)
+	assert.Len(md.Undecoded(), 0)
+	assert.Len(config.Validate(), 0)
+	assert.False(config.Nodes.Enable)
+	assert.Equal("gzip", config.Nodes.StateCompression)
+
+	// the values of the communities are examples
+	assert.Contains(buf.String(), "\n#interfaces = [\"br-ffhb\"]\n")
+	assert.Empty(config.Respondd.Interfaces)
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	config, err := ReadConfigFile("../config_example.toml")
+	assert.NoError(err)
+	assert.Len(config.Validate(), 0)
+
+	config.Respondd.Interfaces = nil
+	config.Nodes.StateCompression = "zip"
+	config.Webserver.Enable = true
+	config.Webserver.Bind = ""
+	config.Respondd.Unicast.Rate = MaxUnicastRate + 1
+	var keys []string
+	for _, err := range config.Validate() {
+		keys = append(keys, err.(*OptionError).Key)
+	}
+	assert.Equal([]string{"respondd.interfaces", "respondd.unicast.rate", "webserver.bind", "nodes.state_compression"}, keys)
+}
+
+func TestConfigLines(t *testing.T) {
+	assert := assert.New(t)
+
+	lines := ConfigLines([]byte(`[respondd]
+enable = true
+# comment = 1
+
+[[nodes.output.meshviewer]]
+version = 2
+
+[[nodes.output.meshviewer]]
+version = 1
+[nodes.output.meshviewer.filter]
+no_owner = false
+`))
+	assert.Equal(1, lines["respondd"])
+	assert.Equal(2, lines["respondd.enable"])
+	assert.NotContains(lines, "respondd.comment")
+	assert.Equal(5, lines["nodes.output.meshviewer[0]"])
+	assert.Equal(6, lines["nodes.output.meshviewer[0].version"])
+	assert.Equal(9, lines["nodes.output.meshviewer[1].version"])
+	assert.Equal(11, lines["nodes.output.meshviewer[1].filter.no_owner"])
+	assert.Equal(6, lines["nodes.output.meshviewer.version"])
+
+	assert.Equal(9, ConfigLine(lines, "nodes.output.meshviewer[1].version"))
+	assert.Equal(8, ConfigLine(lines, "nodes.output.meshviewer[1].nodes_path"))
+	assert.Equal(10, ConfigLine(lines, "nodes.output.meshviewer[1].filter.in_area"))
+	assert.Equal(6, ConfigLine(lines, "nodes.output.meshviewer.version"))
+	assert.Equal(0, ConfigLine(lines, "database"))
+}
@@ -50,3 +50,39 @@ func TestReadConfig(t *testing.T) {
 	assert.Error(err, "not found able")
 	assert.Contains(err.Error(), "no such file or directory")
 }
+
+func TestReadConfigDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	config, err := ReadConfigFile("testdata/config_defaults.toml")
+	assert.NoError(err)
+	assert.True(config.Respondd.Enable)
+	assert.Equal([]string{"bat0"}, config.Respondd.Interfaces)
+	assert.True(config.Nodes.Enable)
+	assert.Equal(time.Minute, config.Nodes.SaveInterval.Duration)
+
+	// the missing options have the defaults printed by yanic config defaults
+	assert.Equal(time.Minute, config.Respondd.CollectInterval.Duration)
+	assert.Equal(100, config.Respondd.Unicast.Rate)
+	assert.Equal("127.0.0.1:8080", config.Webserver.Bind)
+	assert.Equal(10*time.Minute, config.Nodes.OfflineAfter.Duration)
+	assert.Equal(time.Hour, config.Database.DeleteInterval.Duration)
+	assert.Empty(config.Validate())
+
+	// the values of the communities are not applied
+	assert.Equal("", config.Nodes.StatePath)
+	assert.Equal("", config.Webserver.Webroot)
+}
+
+func TestDefaultConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	// a minimal configuration does not collect, cache or serve anything
+	config := DefaultConfig()
+	assert.False(config.Respondd.Enable)
+	assert.Empty(config.Respondd.Interfaces)
+	assert.False(config.Nodes.Enable)
+	assert.Equal("", config.Nodes.StatePath)
+	assert.False(config.Webserver.Enable)
+	assert.Empty(config.Validate())
+}
@@ -0,0 +1,130 @@
+package runtime
+
+import (
+	"log"
+	"sort"
+)
+
+// Types of conflicts
+const (
+	ConflictAddress  = "address"  // node ID answered from different addresses
+	ConflictHostname = "hostname" // node ID answered with different hostnames
+	ConflictMAC      = "mac"      // MAC address claimed by different node IDs
+)
+
+// Conflict of nodes within a collection cycle,
+// caused e.g. by cloned configurations or spoofing
+type Conflict struct {
+	Type   string   `json:"type"`
+	Key    string   `json:"key"`    // node ID or MAC address
+	Values []string `json:"values"` // addresses, hostnames or node IDs
+}
+
+// NodeIDs returns the IDs of the involved nodes
+func (c *Conflict) NodeIDs() []string {
+	if c.Type == ConflictMAC {
+		return c.Values
+	}
+	return []string{c.Key}
+}
+
+// conflictTracker observes the responses of a collection cycle
+type conflictTracker struct {
+	observed map[string]map[string]map[string]bool // type -> key -> values of the current cycle
+	previous []Conflict                            // conflicts of the last finished cycle
+}
+
+func newConflictTracker() *conflictTracker {
+	tracker := &conflictTracker{}
+	tracker.reset()
+	return tracker
+}
+
+func (tracker *conflictTracker) reset() {
+	tracker.observed = map[string]map[string]map[string]bool{
+		ConflictAddress:  make(map[string]map[string]bool),
+		ConflictHostname: make(map[string]map[string]bool),
+		ConflictMAC:      make(map[string]map[string]bool),
+	}
+}
+
+// observe adds a value and returns the conflict if the value is a new conflicting one
+func (tracker *conflictTracker) observe(conflictType, key, value string) *Conflict {
+	if key == "" || value == "" {
+		return nil
+	}
+	values := tracker.observed[conflictType][key]
+	if values == nil {
+		values = make(map[string]bool)
+		tracker.observed[conflictType][key] = values
+	}
+	if values[value] {
+		return nil
+	}
+	values[value] = true
+
+	if len(values) < 2 {
+		return nil
+	}
+	conflict := newConflict(conflictType, key, values)
+	log.Printf("conflict: %s %s seen with %s %v", conflictType, key, conflictValueName(conflictType), conflict.Values)
+	return conflict
+}
+
+// current returns the conflicts of the current cycle
+func (tracker *conflictTracker) current() (result []Conflict) {
+	for conflictType, keys := range tracker.observed {
+		for key, values := range keys {
+			if len(values) > 1 {
+				result = append(result, *newConflict(conflictType, key, values))
+			}
+		}
+	}
+	return
+}
+
+// rotate finishes the current cycle and returns its conflicts
+func (tracker *conflictTracker) rotate() []Conflict {
+	tracker.previous = tracker.current()
+	tracker.reset()
+	return tracker.previous
+}
+
+// conflicts of the last finished and the current cycle
+func (tracker *conflictTracker) conflicts() []Conflict {
+	result := make([]Conflict, 0)
+	seen := make(map[string]bool)
+	for _, conflict := range append(tracker.current(), tracker.previous...) {
+		id := conflict.Type + " " + conflict.Key
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, conflict)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+func newConflict(conflictType, key string, values map[string]bool) *Conflict {
+	conflict := &Conflict{Type: conflictType, Key: key}
+	for value := range values {
+		conflict.Values = append(conflict.Values, value)
+	}
+	sort.Strings(conflict.Values)
+	return conflict
+}
+
+func conflictValueName(conflictType string) string {
+	switch conflictType {
+	case ConflictAddress:
+		return "addresses"
+	case ConflictHostname:
+		return "hostnames"
+	}
+	return "node IDs"
+}
@@ -0,0 +1,65 @@
+package runtime
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+func TestConflicts(t *testing.T) {
+	assert := assert.New(t)
+	nodes := NewNodes(&Config{})
+
+	assert.Len(nodes.Conflicts(), 0)
+
+	response := func(nodeID, hostname, mac string) *data.ResponseData {
+		return &data.ResponseData{
+			NodeInfo: &data.NodeInfo{
+				NodeID:   nodeID,
+				Hostname: hostname,
+				Network:  data.Network{Mac: mac},
+			},
+		}
+	}
+
+	// same node ID from different addresses and with different hostnames
+	nodes.Update("112233445566", response("112233445566", "a", "11:22:33:44:55:66"))
+	nodes.SetAddress("112233445566", &net.UDPAddr{IP: net.ParseIP("fe80::1"), Zone: "eth0"})
+	nodes.Update("112233445566", response("112233445566", "a", "11:22:33:44:55:66"))
+	nodes.SetAddress("112233445566", &net.UDPAddr{IP: net.ParseIP("fe80::1"), Zone: "eth0"})
+	assert.Len(nodes.Conflicts(), 0)
+	assert.False(nodes.List["112233445566"].Conflict)
+
+	nodes.Update("112233445566", response("112233445566", "b", "11:22:33:44:55:66"))
+	nodes.SetAddress("112233445566", &net.UDPAddr{IP: net.ParseIP("fe80::2"), Zone: "eth0"})
+
+	// MAC claimed by another node
+	nodes.Update("aabbccddeeff", response("aabbccddeeff", "c", "11:22:33:44:55:66"))
+
+	conflicts := nodes.Conflicts()
+	assert.Equal([]Conflict{
+		{Type: ConflictAddress, Key: "112233445566", Values: []string{"fe80::1", "fe80::2"}},
+		{Type: ConflictHostname, Key: "112233445566", Values: []string{"a", "b"}},
+		{Type: ConflictMAC, Key: "11:22:33:44:55:66", Values: []string{"112233445566", "aabbccddeeff"}},
+	}, conflicts)
+	assert.True(nodes.List["112233445566"].Conflict)
+	assert.True(nodes.List["aabbccddeeff"].Conflict)
+
+	// conflicts of the last cycle are kept
+	nodes.NewCycle()
+	assert.Len(nodes.Conflicts(), 3)
+	assert.True(nodes.List["112233445566"].Conflict)
+
+	// no more conflicts
+	nodes.NewCycle()
+	assert.Len(nodes.Conflicts(), 0)
+	assert.False(nodes.List["112233445566"].Conflict)
+	assert.False(nodes.List["aabbccddeeff"].Conflict)
+
+	// unknown node
+	nodes.SetAddress("000000000000", &net.UDPAddr{IP: net.ParseIP("fe80::1")})
+	assert.Len(nodes.Conflicts(), 0)
+}
@@ -16,6 +16,12 @@ type Node struct {
 	Statistics *data.Statistics `json:"statistics"`
 	Nodeinfo   *data.NodeInfo   `json:"nodeinfo"`
 	Neighbours *data.Neighbours `json:"-"`
+	Conflict   bool             `json:"-"` // conflicts with other nodes in the last or current cycle
+
+	Overrides   *NodeOverrides `json:"overrides,omitempty"`   // values set by an administrator
+	Maintenance bool           `json:"maintenance,omitempty"` // the node is in maintenance, e.g. no alerts should be sent
+
+	Tags map[string]string `json:"-"` // custom tags of the overlay
 }
 
 // Link represents a link between two nodes
@@ -25,6 +31,23 @@ type Link struct {
 	TargetID  string
 	TargetMAC string
 	TQ        int
+	VPN       bool // the link goes through the mesh VPN, see IsVPNLink
+}
+
+// Copy returns a shallow copy of the node.
+// The referenced data is shared, it is never modified after being stored.
+func (node *Node) Copy() *Node {
+	c := *node
+	return &c
+}
+
+// WithOverrides returns a copy of the node with the overridden values applied
+func (node *Node) WithOverrides() *Node {
+	c := node.Copy()
+	if node.Overrides != nil && node.Nodeinfo != nil {
+		c.Nodeinfo = node.Overrides.Apply(node.Nodeinfo)
+	}
+	return c
 }
 
 // IsGateway returns whether the node is a gateway
@@ -34,3 +57,34 @@ func (node *Node) IsGateway() bool {
 	}
 	return false
 }
+
+// MeshVPNPeers returns the uptime in seconds of the established mesh VPN peers by name
+func (node *Node) MeshVPNPeers() map[string]float64 {
+	if stats := node.Statistics; stats != nil && stats.MeshVPN != nil {
+		return stats.MeshVPN.EstablishedPeers(node.Lastseen.GetTime())
+	}
+	return nil
+}
+
+func (node *Node) interfaceType(mac string) string {
+	if node == nil || node.Nodeinfo == nil {
+		return ""
+	}
+	return node.Nodeinfo.Network.InterfaceType(mac)
+}
+
+// IsVPNLink returns whether the link between the interfaces of both nodes goes through the mesh VPN.
+// This is the case if one of both interfaces is a tunnel interface or if a gateway is linked
+// to a node with an established mesh VPN over a non-wireless interface
+// (e.g. WireGuard, whose interfaces are not always reported as tunnel).
+func IsVPNLink(source *Node, sourceMAC string, target *Node, targetMAC string) bool {
+	sourceType, targetType := source.interfaceType(sourceMAC), target.interfaceType(targetMAC)
+	if sourceType == "tunnel" || targetType == "tunnel" {
+		return true
+	}
+	if sourceType == "wireless" || targetType == "wireless" || source == nil || target == nil {
+		return false
+	}
+	return (target.IsGateway() && len(source.MeshVPNPeers()) > 0) ||
+		(source.IsGateway() && len(target.MeshVPNPeers()) > 0)
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/jsontime"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +23,43 @@ func TestNode(t *testing.T) {
 	node.Nodeinfo.VPN = false
 	assert.False(node.IsGateway())
 }
+
+func TestIsVPNLink(t *testing.T) {
+	assert := assert.New(t)
+
+	newNode := func(gateway bool, wireless, other, tunnel string) *Node {
+		mesh := &data.BatInterface{}
+		mesh.Interfaces.Wireless = []string{wireless}
+		mesh.Interfaces.Other = []string{other}
+		mesh.Interfaces.Tunnel = []string{tunnel}
+		return &Node{
+			Lastseen: jsontime.Now(),
+			Nodeinfo: &data.NodeInfo{
+				VPN:     gateway,
+				Network: data.Network{Mesh: map[string]*data.BatInterface{"bat0": mesh}},
+			},
+		}
+	}
+	node := newNode(false, "a:wifi", "a:lan", "a:vpn")
+	gateway := newNode(true, "", "gw:lan", "")
+	other := newNode(false, "b:wifi", "b:lan", "")
+
+	assert.True(IsVPNLink(node, "a:vpn", gateway, "gw:lan"))
+	assert.True(IsVPNLink(gateway, "gw:lan", node, "a:vpn"), "tunnel interface of the target")
+	assert.False(IsVPNLink(node, "a:wifi", other, "b:wifi"))
+	assert.False(IsVPNLink(node, "a:lan", gateway, "gw:lan"), "no established mesh VPN")
+	assert.False(IsVPNLink(node, "a:lan", nil, "unknown"))
+
+	// WireGuard does not report its interface as tunnel
+	node.Statistics = &data.Statistics{MeshVPN: &data.MeshVPN{
+		Provider: "wireguard",
+		Peers: map[string]*data.MeshVPNPeerLink{
+			"gw": {LatestHandshake: float64(node.Lastseen.Unix())},
+		},
+	}}
+	assert.Equal(map[string]float64{"gw": 0}, node.MeshVPNPeers())
+	assert.True(IsVPNLink(node, "a:lan", gateway, "gw:lan"))
+	assert.True(IsVPNLink(gateway, "gw:lan", node, "a:lan"))
+	assert.False(IsVPNLink(node, "a:lan", other, "b:lan"), "not linked to a gateway")
+	assert.False(IsVPNLink(node, "a:wifi", gateway, "gw:lan"), "wireless link")
+}
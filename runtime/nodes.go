@@ -3,6 +3,7 @@ package runtime
 import (
 	"encoding/json"
 	"log"
+	"net"
 	"os"
 	"sync"
 	"time"
@@ -15,8 +16,19 @@ import (
 type Nodes struct {
 	List          map[string]*Node  `json:"nodes"` // the current nodemap, indexed by node ID
 	ifaceToNodeID map[string]string // mapping from MAC address to NodeID
-	config        *Config
+	config        *Config           // guarded by configMutex, it is replaced on reload
+	configMutex   sync.Mutex
+	conflicts     *conflictTracker
+	version       uint64          // incremented on every modification
+	changed       map[string]bool // IDs of the nodes changed since the last save
+	state         *stateStore
 	sync.RWMutex
+
+	snapshot        *Nodes // cached result of Snapshot
+	snapshotVersion uint64
+	snapshotOverlay *Overlay
+	snapshotMutex   sync.Mutex
+	overlay         *overlayFile
 }
 
 // NewNodes create Nodes structs
@@ -39,6 +51,25 @@ func (nodes *Nodes) Start() {
 	go nodes.worker()
 }
 
+// SetConfig replaces the configuration of the running nodes, e.g. on reload
+func (nodes *Nodes) SetConfig(config *Config) {
+	nodes.configMutex.Lock()
+	nodes.config = config
+	nodes.configMutex.Unlock()
+
+	// the snapshot references the config and may depend on the overlay
+	nodes.snapshotMutex.Lock()
+	nodes.snapshot = nil
+	nodes.snapshotMutex.Unlock()
+}
+
+// getConfig returns the current configuration
+func (nodes *Nodes) getConfig() *Config {
+	nodes.configMutex.Lock()
+	defer nodes.configMutex.Unlock()
+	return nodes.config
+}
+
 func (nodes *Nodes) AddNode(node *Node) {
 	nodeinfo := node.Nodeinfo
 	if nodeinfo == nil || nodeinfo.NodeID == "" {
@@ -48,13 +79,15 @@ func (nodes *Nodes) AddNode(node *Node) {
 	defer nodes.Unlock()
 	nodes.List[nodeinfo.NodeID] = node
 	nodes.readIfaces(nodeinfo)
+	nodes.markChanged(nodeinfo.NodeID)
 }
 
-// Update a Node
+// Update a Node and return a copy of it
 func (nodes *Nodes) Update(nodeID string, res *data.ResponseData) *Node {
 	now := jsontime.Now()
 
 	nodes.Lock()
+	defer nodes.Unlock()
 	node, _ := nodes.List[nodeID]
 
 	if node == nil {
@@ -65,28 +98,182 @@ func (nodes *Nodes) Update(nodeID string, res *data.ResponseData) *Node {
 	}
 	if res.NodeInfo != nil {
 		nodes.readIfaces(res.NodeInfo)
+		nodes.observe(ConflictHostname, nodeID, res.NodeInfo.Hostname)
+		for _, mac := range nodeinfoAddresses(res.NodeInfo) {
+			nodes.observe(ConflictMAC, mac, nodeID)
+		}
 	}
-	nodes.Unlock()
 
-	// Update wireless statistics
-	if statistics := res.Statistics; statistics != nil {
+	// Update wireless statistics and rates
+	if statistics := res.Statistics; statistics != nil && node.Statistics != nil {
 		// Update channel utilization if previous statistics are present
-		if node.Statistics != nil && node.Statistics.Wireless != nil && statistics.Wireless != nil {
+		if node.Statistics.Wireless != nil && statistics.Wireless != nil {
 			statistics.Wireless.SetUtilization(node.Statistics.Wireless)
 		}
+
+		nodeinfo := res.NodeInfo
+		if nodeinfo == nil {
+			nodeinfo = node.Nodeinfo
+		}
+		nproc := 1
+		if nodeinfo != nil {
+			nproc = nodeinfo.Hardware.Nproc
+		}
+		statistics.SetRates(node.Statistics, nproc)
 	}
 
-	// Update fields
+	// Update fields, keep the previous data of providers missing
+	// in the response (e.g. if they were requested separately)
 	node.Lastseen = now
 	node.Online = true
-	node.Neighbours = res.Neighbours
-	node.Nodeinfo = res.NodeInfo
-	node.Statistics = res.Statistics
+	if res.Neighbours != nil {
+		node.Neighbours = res.Neighbours
+	}
+	if res.NodeInfo != nil {
+		node.Nodeinfo = res.NodeInfo
+	}
+	if res.Statistics != nil {
+		node.Statistics = res.Statistics
+	}
+	nodes.markChanged(nodeID)
+
+	return node.WithOverrides()
+}
+
+// SetAddress sets the last known address of a node
+// and checks it for conflicts with other responses of the same node ID
+func (nodes *Nodes) SetAddress(nodeID string, addr *net.UDPAddr) {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	if node := nodes.List[nodeID]; node != nil {
+		node.Address = addr
+		nodes.observe(ConflictAddress, nodeID, addr.IP.String())
+		nodes.version++
+	}
+}
+
+// NewCycle starts a new collection cycle for the conflict detection
+func (nodes *Nodes) NewCycle() {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	conflicting := make(map[string]bool)
+	for _, conflict := range nodes.tracker().rotate() {
+		for _, nodeID := range conflict.NodeIDs() {
+			conflicting[nodeID] = true
+		}
+	}
+	if len(conflicting) > 0 {
+		log.Printf("%d nodes had conflicts in the last cycle", len(conflicting))
+	}
+
+	for nodeID, node := range nodes.List {
+		if node.Conflict != conflicting[nodeID] {
+			node.Conflict = conflicting[nodeID]
+			nodes.version++
+		}
+	}
+}
+
+// Conflicts returns the conflicts of the last and the current collection cycle
+func (nodes *Nodes) Conflicts() []Conflict {
+	nodes.RLock()
+	defer nodes.RUnlock()
+
+	if nodes.conflicts == nil {
+		return []Conflict{}
+	}
+	return nodes.conflicts.conflicts()
+}
+
+// observe a value of the current cycle and flag the nodes of a new conflict
+func (nodes *Nodes) observe(conflictType, key, value string) {
+	if conflict := nodes.tracker().observe(conflictType, key, value); conflict != nil {
+		for _, nodeID := range conflict.NodeIDs() {
+			if node := nodes.List[nodeID]; node != nil && !node.Conflict {
+				node.Conflict = true
+				nodes.version++
+			}
+		}
+	}
+}
+
+func (nodes *Nodes) tracker() *conflictTracker {
+	if nodes.conflicts == nil {
+		nodes.conflicts = newConflictTracker()
+	}
+	return nodes.conflicts
+}
+
+// markChanged marks a node to be persisted, the lock must be held
+func (nodes *Nodes) markChanged(nodeID string) {
+	if nodes.changed == nil {
+		nodes.changed = make(map[string]bool)
+	}
+	nodes.changed[nodeID] = true
+	nodes.version++
+}
 
-	return node
+// Version returns a number, which changes on every modification of the nodes
+func (nodes *Nodes) Version() uint64 {
+	nodes.RLock()
+	defer nodes.RUnlock()
+	return nodes.version
 }
 
-// Select selects a list of nodes to be returned
+// Snapshot returns a consistent copy of the nodes, which is not modified afterwards.
+// The overridden values of the nodes and the overlay are applied.
+// The snapshot is shared between all callers until the nodes are modified,
+// so it must not be changed by the caller.
+func (nodes *Nodes) Snapshot() *Nodes {
+	nodes.snapshotMutex.Lock()
+	defer nodes.snapshotMutex.Unlock()
+
+	overlay := nodes.overlayData()
+
+	nodes.RLock()
+	defer nodes.RUnlock()
+
+	if nodes.snapshot != nil && nodes.snapshotVersion == nodes.version && nodes.snapshotOverlay == overlay {
+		return nodes.snapshot
+	}
+
+	snapshot := &Nodes{
+		List:          make(map[string]*Node, len(nodes.List)),
+		ifaceToNodeID: make(map[string]string, len(nodes.ifaceToNodeID)),
+		config:        nodes.getConfig(),
+	}
+	for nodeID, node := range nodes.List {
+		snapshot.List[nodeID] = node.WithOverrides()
+	}
+	for mac, nodeID := range nodes.ifaceToNodeID {
+		snapshot.ifaceToNodeID[mac] = nodeID
+	}
+	if overlay != nil {
+		overlay.apply(snapshot)
+	}
+
+	nodes.snapshot = snapshot
+	nodes.snapshotVersion = nodes.version
+	nodes.snapshotOverlay = overlay
+	return snapshot
+}
+
+// overlayData returns the overlay of the configured overlay path or nil
+func (nodes *Nodes) overlayData() *Overlay {
+	config := nodes.getConfig()
+	if config == nil || config.Nodes.OverlayPath == "" {
+		return nil
+	}
+	if nodes.overlay == nil || nodes.overlay.path != config.Nodes.OverlayPath {
+		nodes.overlay = &overlayFile{path: config.Nodes.OverlayPath}
+	}
+	return nodes.overlay.get()
+}
+
+// Select selects a list of nodes to be returned.
+// The returned nodes must only be accessed while holding the lock.
 func (nodes *Nodes) Select(f func(*Node) bool) []*Node {
 	nodes.RLock()
 	defer nodes.RUnlock()
@@ -121,6 +308,7 @@ func (nodes *Nodes) NodeLinks(node *Node) (result []Link) {
 					TargetID:  neighbourID,
 					TargetMAC: neighbourMAC,
 					TQ:        link.Tq,
+					VPN:       IsVPNLink(node, sourceMAC, nodes.List[neighbourID], neighbourMAC),
 				})
 			}
 		}
@@ -130,9 +318,14 @@ func (nodes *Nodes) NodeLinks(node *Node) (result []Link) {
 
 // Periodically saves the cached DB to json file
 func (nodes *Nodes) worker() {
-	c := time.Tick(nodes.config.Nodes.SaveInterval.Duration)
-
-	for range c {
+	for {
+		// the interval may be changed by a reload
+		interval := nodes.getConfig().Nodes.SaveInterval.Duration
+		if interval <= 0 {
+			time.Sleep(time.Second)
+			continue
+		}
+		time.Sleep(interval)
 		nodes.expire()
 		nodes.save()
 	}
@@ -141,49 +334,46 @@ func (nodes *Nodes) worker() {
 // Expires nodes and set nodes offline
 func (nodes *Nodes) expire() {
 	now := jsontime.Now()
-
-	// Nodes last seen before expireAfter will be removed
-	prunePeriod := nodes.config.Nodes.PruneAfter.Duration
-	if prunePeriod == 0 {
-		prunePeriod = time.Hour * 24 * 7 // our default
-	}
-	pruneAfter := now.Add(-prunePeriod)
-
-	// Nodes last seen within OfflineAfter are changed to 'offline'
-	offlineAfter := now.Add(-nodes.config.Nodes.OfflineAfter.Duration)
+	config := nodes.getConfig()
 
 	// Locking foo
 	nodes.Lock()
 	defer nodes.Unlock()
 
 	for id, node := range nodes.List {
+		// Nodes last seen before pruneAfter will be removed
+		pruneAfter := now.Add(-config.PruneAfter(id, node))
+
+		// Nodes last seen within offlineAfter are changed to 'offline'
+		offlineAfter := now.Add(-config.OfflineAfter(id, node))
+
 		if node.Lastseen.Before(pruneAfter) {
 			// expire
 			delete(nodes.List, id)
-		} else if node.Lastseen.Before(offlineAfter) {
+			nodes.markChanged(id)
+		} else if node.Lastseen.Before(offlineAfter) && node.Online {
 			// set to offline
 			node.Online = false
+			nodes.markChanged(id)
 		}
 	}
 }
 
+// Policy returns the first configured policy matching the node or nil
+func (nodes *Nodes) Policy(nodeID string, node *Node) *NodePolicy {
+	return nodes.getConfig().Policy(nodeID, node)
+}
+
 // adds the nodes interface addresses to the internal map
 func (nodes *Nodes) readIfaces(nodeinfo *data.NodeInfo) {
 	nodeID := nodeinfo.NodeID
-	network := nodeinfo.Network
 
 	if nodeID == "" {
 		log.Println("nodeID missing in nodeinfo")
 		return
 	}
 
-	addresses := []string{network.Mac}
-
-	for _, batinterface := range network.Mesh {
-		addresses = append(addresses, batinterface.Addresses()...)
-	}
-
-	for _, mac := range addresses {
+	for _, mac := range nodeinfoAddresses(nodeinfo) {
 		if oldNodeID, _ := nodes.ifaceToNodeID[mac]; oldNodeID != nodeID {
 			if oldNodeID != "" {
 				log.Printf("override nodeID from %s to %s on MAC address %s", oldNodeID, nodeID, mac)
@@ -193,54 +383,103 @@ func (nodes *Nodes) readIfaces(nodeinfo *data.NodeInfo) {
 	}
 }
 
-func (nodes *Nodes) load() {
-	path := nodes.config.Nodes.StatePath
+// returns the MAC addresses of the nodeinfo
+func nodeinfoAddresses(nodeinfo *data.NodeInfo) []string {
+	network := nodeinfo.Network
+	addresses := []string{network.Mac}
 
-	if f, err := os.Open(path); err == nil { // transform data to legacy meshviewer
-		if err = json.NewDecoder(f).Decode(nodes); err == nil {
-			log.Println("loaded", len(nodes.List), "nodes")
+	for _, batinterface := range network.Mesh {
+		addresses = append(addresses, batinterface.Addresses()...)
+	}
+	return addresses
+}
 
-			nodes.Lock()
-			for _, node := range nodes.List {
-				if node.Nodeinfo != nil {
-					nodes.readIfaces(node.Nodeinfo)
-				}
-			}
-			nodes.Unlock()
+func (nodes *Nodes) load() {
+	list, err := nodes.stateStore().load()
+	if err != nil {
+		log.Println("failed to load cached nodes:", err)
+		return
+	}
+	log.Println("loaded", len(list), "nodes")
 
-		} else {
-			log.Println("failed to unmarshal nodes:", err)
+	nodes.Lock()
+	for nodeID, node := range list {
+		nodes.List[nodeID] = node
+		if node.Nodeinfo != nil {
+			nodes.readIfaces(node.Nodeinfo)
 		}
-	} else {
-		log.Println("failed to load cached nodes:", err)
 	}
+	nodes.version++
+	nodes.Unlock()
 }
 
+// save writes the changed nodes to the state log
+// or a new snapshot of all nodes if the log became too large
 func (nodes *Nodes) save() {
-	// Locking foo
-	nodes.RLock()
-	defer nodes.RUnlock()
+	store := nodes.stateStore()
 
-	// serialize nodes
-	SaveJSON(nodes, nodes.config.Nodes.StatePath)
+	nodes.Lock()
+	changes := make([]stateChange, 0, len(nodes.changed))
+	for nodeID := range nodes.changed {
+		change := stateChange{NodeID: nodeID}
+		if node := nodes.List[nodeID]; node != nil {
+			change.Node = node.Copy()
+		}
+		changes = append(changes, change)
+	}
+	nodes.changed = nil
+	nodes.Unlock()
+
+	var err error
+	if store.needsCompaction() {
+		// the copy contains at least all taken changes
+		nodes.RLock()
+		list := make(map[string]*Node, len(nodes.List))
+		for nodeID, node := range nodes.List {
+			list[nodeID] = node.Copy()
+		}
+		nodes.RUnlock()
+
+		err = store.compact(list)
+	} else {
+		err = store.append(changes)
+	}
+	if err != nil {
+		log.Println("failed to save nodes:", err)
+		// write all nodes on the next try
+		store.compacted = time.Time{}
+	}
 }
 
-// SaveJSON to path
-func SaveJSON(input interface{}, outputFile string) {
+// stateStore returns the store of the configured state path
+func (nodes *Nodes) stateStore() *stateStore {
+	config := nodes.getConfig().Nodes
+	if nodes.state == nil || nodes.state.path != config.StatePath {
+		nodes.state = &stateStore{path: config.StatePath}
+	}
+	nodes.state.compress = config.StateCompression != StateCompressionNone
+	nodes.state.compaction = config.StateCompaction.Duration
+	return nodes.state
+}
+
+// SaveJSON to path, the file is replaced at once
+func SaveJSON(input interface{}, outputFile string) error {
 	tmpFile := outputFile + ".tmp"
 
 	f, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
-		log.Panic(err)
+		return err
 	}
 
 	err = json.NewEncoder(f).Encode(input)
-	if err != nil {
-		log.Panic(err)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
-
-	f.Close()
-	if err := os.Rename(tmpFile, outputFile); err != nil {
-		log.Panic(err)
+	if err == nil {
+		err = os.Rename(tmpFile, outputFile)
+	}
+	if err != nil {
+		os.Remove(tmpFile)
 	}
+	return err
 }
@@ -69,18 +69,20 @@ func TestLoadAndSave(t *testing.T) {
 	config.Nodes.StatePath = tmpfile.Name()
 	nodes.save()
 	os.Remove(tmpfile.Name())
+	os.Remove(tmpfile.Name() + ".bak")
 
-	assert.PanicsWithValue("open /dev/null.tmp: permission denied", func() {
-		SaveJSON(nodes, "/dev/null")
-	})
+	assert.EqualError(SaveJSON(nodes, "/nonexistent/nodes.json"), "open /nonexistent/nodes.json.tmp: no such file or directory")
 
 	tmpfile, _ = ioutil.TempFile("/tmp", "nodes")
-	assert.PanicsWithValue("json: unsupported type: func() string", func() {
-		SaveJSON(tmpfile.Name, tmpfile.Name())
-	})
+	assert.EqualError(SaveJSON(tmpfile.Name, tmpfile.Name()), "json: unsupported type: func() string")
+	_, err := os.Stat(tmpfile.Name() + ".tmp")
+	assert.True(os.IsNotExist(err))
 	os.Remove(tmpfile.Name())
 
-	//TODO how to test easy a failing renaming
+	// a directory can not be replaced by the file
+	dir, _ := ioutil.TempDir("/tmp", "nodes")
+	assert.Error(SaveJSON(nodes, dir))
+	os.RemoveAll(dir)
 
 	assert.Len(nodes.List, 2)
 }
@@ -108,6 +110,22 @@ func TestUpdateNodes(t *testing.T) {
 	nodes.Update("abcdef012345", res)
 
 	assert.Len(nodes.List, 1)
+
+	// keep the previous providers on a partial response
+	node := nodes.Update("abcdef012345", &data.ResponseData{
+		Statistics: &data.Statistics{},
+	})
+	assert.Equal(res.NodeInfo, node.Nodeinfo)
+	assert.Equal(res.Neighbours, node.Neighbours)
+	assert.NotEqual(res.Statistics, node.Statistics)
+
+	// rates of the counters between both statistics
+	res.NodeInfo.Hardware.Nproc = 2
+	node = nodes.Update("abcdef012345", &data.ResponseData{
+		Statistics: &data.Statistics{Uptime: 60, Idletime: 90},
+	})
+	assert.NotNil(node.Statistics.Rates)
+	assert.Equal(0.25, *node.Statistics.Rates.CPUUsage)
 }
 
 func TestSelectNodes(t *testing.T) {
@@ -206,3 +224,50 @@ func TestLinksNodes(t *testing.T) {
 	nodeid := nodes.GetNodeIDbyMAC("f4:f2:6d:d7:a3:0a")
 	assert.Equal("f4f26dd7a30a", nodeid)
 }
+
+func TestSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := NewNodes(&Config{})
+	nodes.Update("abcdef012345", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{NodeID: "abcdef012345"},
+	})
+
+	snapshot := nodes.Snapshot()
+	assert.Len(snapshot.List, 1)
+	assert.Equal("abcdef012345", snapshot.GetNodeIDbyMAC(""))
+
+	// cached until modified
+	assert.True(snapshot == nodes.Snapshot())
+
+	// modifications do not change the snapshot
+	statistics := &data.Statistics{}
+	nodes.Update("abcdef012345", &data.ResponseData{Statistics: statistics})
+	nodes.Update("000000000000", &data.ResponseData{})
+	assert.Nil(snapshot.List["abcdef012345"].Statistics)
+	assert.Len(snapshot.List, 1)
+
+	updated := nodes.Snapshot()
+	assert.False(snapshot == updated)
+	assert.Len(updated.List, 2)
+	assert.Equal(statistics, updated.List["abcdef012345"].Statistics)
+	assert.False(nodes.List["abcdef012345"] == updated.List["abcdef012345"])
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	nodes := NewNodes(&Config{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			nodes.Update("abcdef012345", &data.ResponseData{Statistics: &data.Statistics{}})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		for _, node := range nodes.Snapshot().List {
+			_ = node.Online && node.Statistics != nil
+		}
+	}
+	<-done
+}
@@ -0,0 +1,208 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Types of options
+const (
+	OptionBool     = "bool"
+	OptionString   = "string"
+	OptionInt      = "int"
+	OptionFloat    = "float"
+	OptionDuration = "duration" // string like "5m", see Duration
+	OptionList     = "list"     // list of strings
+	OptionTable    = "table"
+	OptionTables   = "tables" // array of tables, e.g. [[nodes.output.<type>.transform]]
+)
+
+// Option declares an option of a configuration section or an adapter
+type Option struct {
+	Name     string
+	Type     string
+	Default  interface{} // used if the option is missing, in the format of the TOML decoder (e.g. int64)
+	Example  interface{} // printed instead of a missing default, it is not used
+	Required bool
+	Doc      string
+}
+
+// Options of a configuration section or an adapter
+type Options []Option
+
+// OptionError is a problem of the option at the given key, e.g. nodes.output.meshviewer[0].version
+type OptionError struct {
+	Key string
+	Msg string
+}
+
+func (err *OptionError) Error() string {
+	return err.Key + ": " + err.Msg
+}
+
+// Get returns the option with the given name or nil
+func (options Options) Get(name string) *Option {
+	for i := range options {
+		if options[i].Name == name {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks the types of the configuration, sets the defaults of missing options
+// and returns all problems. The keys of the problems start with the given prefix.
+func (options Options) Validate(prefix string, config map[string]interface{}) (errs []error) {
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		option := options.Get(name)
+		if option == nil {
+			errs = append(errs, &OptionError{Key: prefix + "." + name, Msg: "unknown option"})
+			continue
+		}
+		if err := option.check(config[name]); err != nil {
+			errs = append(errs, &OptionError{Key: prefix + "." + name, Msg: err.Error()})
+		}
+	}
+
+	for _, option := range options {
+		if _, ok := config[option.Name]; ok {
+			continue
+		}
+		if option.Required {
+			errs = append(errs, &OptionError{Key: prefix + "." + option.Name, Msg: "missing required option"})
+		} else if option.Default != nil {
+			config[option.Name] = option.Default
+		}
+	}
+	return
+}
+
+// check returns an error if the value has the wrong type
+func (option *Option) check(value interface{}) error {
+	ok := false
+	switch option.Type {
+	case OptionBool:
+		_, ok = value.(bool)
+	case OptionString:
+		_, ok = value.(string)
+	case OptionInt:
+		_, ok = value.(int64)
+	case OptionFloat:
+		_, ok = value.(float64)
+	case OptionDuration:
+		var duration Duration
+		if err := duration.UnmarshalTOML(value); err != nil {
+			return err
+		}
+		ok = true
+	case OptionList:
+		var list []interface{}
+		if list, ok = value.([]interface{}); ok {
+			for _, item := range list {
+				if _, isString := item.(string); !isString {
+					return fmt.Errorf("expected a list of strings, got %v", value)
+				}
+			}
+		}
+	case OptionTable:
+		_, ok = value.(map[string]interface{})
+	case OptionTables:
+		_, ok = value.([]map[string]interface{})
+		if list, isList := value.([]interface{}); isList && len(list) == 0 {
+			// an empty array as written by Write
+			ok = true
+		}
+	default:
+		ok = true
+	}
+	if !ok {
+		return fmt.Errorf("expected %s, got %s", option.Type, formatValue(value))
+	}
+	return nil
+}
+
+// Write writes the options as annotated TOML, options without defaults or all if commented are commented out
+func (options Options) Write(w io.Writer, commented bool) {
+	for _, option := range options {
+		if option.Doc != "" {
+			for _, line := range strings.Split(option.Doc, "\n") {
+				fmt.Fprintf(w, "# %s\n", line)
+			}
+		}
+		if option.Required {
+			fmt.Fprintln(w, "# (required)")
+		}
+		value := option.Default
+		comment := ""
+		if value == nil || commented {
+			comment = "#"
+		}
+		if value == nil {
+			value = option.Example
+		}
+		if value == nil {
+			value = option.zero()
+		}
+		fmt.Fprintf(w, "%s%s = %s\n", comment, option.Name, formatValue(value))
+	}
+}
+
+func (option *Option) zero() interface{} {
+	switch option.Type {
+	case OptionBool:
+		return false
+	case OptionInt:
+		return int64(0)
+	case OptionFloat:
+		return 0.0
+	case OptionDuration:
+		return "0s"
+	case OptionList, OptionTables:
+		return []interface{}{}
+	case OptionTable:
+		return map[string]interface{}{}
+	}
+	return ""
+}
+
+// formatValue returns the value in TOML syntax
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		// keep floats distinguishable from integers
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = key + " = " + formatValue(v[key])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,84 @@
+package runtime
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	options := Options{
+		{Name: "enable", Type: OptionBool, Default: true},
+		{Name: "path", Type: OptionString, Required: true},
+		{Name: "version", Type: OptionInt, Default: int64(2)},
+		{Name: "interval", Type: OptionDuration},
+		{Name: "blacklist", Type: OptionList},
+		{Name: "latitude", Type: OptionFloat},
+		{Name: "transform", Type: OptionTables},
+	}
+
+	config := map[string]interface{}{"path": "/tmp/nodes.json", "transform": []interface{}{}}
+	assert.Len(options.Validate("nodes.output.a[0]", config), 0)
+	assert.Equal(true, config["enable"])
+	assert.Equal(int64(2), config["version"])
+	assert.NotContains(config, "interval")
+
+	config = map[string]interface{}{"path": "/tmp/nodes.json", "transform": []map[string]interface{}{{"type": "a"}}}
+	assert.Len(options.Validate("nodes.output.a[0]", config), 0)
+
+	config = map[string]interface{}{
+		"transform": map[string]interface{}{},
+		"version":   "2",
+		"interval":  "5x",
+		"blacklist": []interface{}{"a", int64(1)},
+		"latitude":  int64(53),
+		"unknown":   true,
+	}
+	errs := options.Validate("nodes.output.a[1]", config)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal([]string{
+		"nodes.output.a[1].blacklist: expected a list of strings, got [a 1]",
+		"nodes.output.a[1].interval: invalid duration unit: x",
+		"nodes.output.a[1].latitude: expected float, got 53",
+		"nodes.output.a[1].transform: expected tables, got {}",
+		"nodes.output.a[1].unknown: unknown option",
+		`nodes.output.a[1].version: expected int, got "2"`,
+		"nodes.output.a[1].path: missing required option",
+	}, msgs)
+}
+
+func TestOptionsWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	options := Options{
+		{Name: "enable", Type: OptionBool, Default: true, Doc: "Enables it"},
+		{Name: "path", Type: OptionString, Required: true},
+		{Name: "sites", Type: OptionList, Default: []interface{}{"ffhb"}},
+		{Name: "tags", Type: OptionTable},
+	}
+	var buf bytes.Buffer
+	options.Write(&buf, false)
+	assert.Equal(`# Enables it
+enable = true
+# (required)
+#path = ""
+sites = ["ffhb"]
+#tags = {}
+`, buf.String())
+}
+
+func TestFormatValue(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`"a\"b"`, formatValue(`a"b`))
+	assert.Equal("0.0", formatValue(0.0))
+	assert.Equal("53.07", formatValue(53.07))
+	assert.Equal("2", formatValue(int64(2)))
+	assert.Equal(`{a = 1, b = ["c"]}`, formatValue(map[string]interface{}{"b": []interface{}{"c"}, "a": int64(1)}))
+}
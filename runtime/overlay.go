@@ -0,0 +1,215 @@
+package runtime
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/jsontime"
+)
+
+// Overlay of static node data, which is merged onto the collected nodes
+type Overlay struct {
+	Nodes []*OverlayNode `toml:"node" json:"nodes"`
+}
+
+// OverlayNode fills in missing data of a node, matched by node ID or MAC address,
+// or defines a virtual node, which does not run respondd
+type OverlayNode struct {
+	NodeID     string            `toml:"node_id" json:"node_id"`
+	MAC        string            `toml:"mac" json:"mac"`
+	Hostname   string            `toml:"hostname" json:"hostname"`
+	Owner      string            `toml:"owner" json:"owner"`
+	SiteCode   string            `toml:"site_code" json:"site_code"`
+	DomainCode string            `toml:"domain_code" json:"domain_code"`
+	Model      string            `toml:"model" json:"model"`
+	Latitude   *float64          `toml:"latitude" json:"latitude"`
+	Longitude  *float64          `toml:"longitude" json:"longitude"`
+	Tags       map[string]string `toml:"tags" json:"tags"`
+
+	Virtual bool          `toml:"virtual" json:"virtual"` // always online node with fixed links
+	Gateway bool          `toml:"gateway" json:"gateway"` // the virtual node is a gateway
+	Links   []OverlayLink `toml:"link" json:"links"`      // links of the virtual node
+}
+
+// OverlayLink is a fixed link of a virtual node
+type OverlayLink struct {
+	Target string `toml:"target" json:"target"` // node ID
+	TQ     int    `toml:"tq" json:"tq"`         // link quality from 0 to 255 (default: 255)
+}
+
+// ReadOverlayFile reads an overlay from a JSON (*.json) or TOML file
+func ReadOverlayFile(path string) (*Overlay, error) {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := &Overlay{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(file, overlay)
+	} else {
+		err = toml.Unmarshal(file, overlay)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return overlay, nil
+}
+
+// apply merges the overlay onto the copied nodes of a snapshot
+func (overlay *Overlay) apply(snapshot *Nodes) {
+	now := jsontime.Now()
+	var virtual []*OverlayNode
+
+	for _, entry := range overlay.Nodes {
+		nodeID := entry.NodeID
+		if nodeID == "" {
+			nodeID = snapshot.ifaceToNodeID[entry.MAC]
+		}
+		if nodeID == "" {
+			continue
+		}
+
+		node := snapshot.List[nodeID]
+		if node == nil || node.Nodeinfo == nil {
+			if !entry.Virtual {
+				continue
+			}
+			node = &Node{
+				Firstseen: now,
+				Nodeinfo:  entry.nodeinfo(nodeID),
+			}
+			snapshot.List[nodeID] = node
+			for _, mac := range nodeinfoAddresses(node.Nodeinfo) {
+				snapshot.ifaceToNodeID[mac] = nodeID
+			}
+			virtual = append(virtual, entry)
+		}
+		if entry.Virtual {
+			node.Lastseen = now
+			node.Online = true
+		}
+		node.Nodeinfo = entry.fill(node.Nodeinfo)
+		if len(entry.Tags) > 0 {
+			node.Tags = entry.Tags
+		}
+	}
+
+	// add the links after all virtual nodes are known
+	for _, entry := range virtual {
+		node := snapshot.List[entry.NodeID]
+		mac := node.Nodeinfo.Network.Mac
+		links := make(map[string]data.BatmanLink)
+		for _, link := range entry.Links {
+			target := snapshot.List[link.Target]
+			if target == nil || target.Nodeinfo == nil || target.Nodeinfo.Network.Mac == "" {
+				continue
+			}
+			tq := link.TQ
+			if tq <= 0 {
+				tq = 255
+			}
+			links[target.Nodeinfo.Network.Mac] = data.BatmanLink{Tq: tq}
+		}
+		node.Neighbours = &data.Neighbours{
+			NodeID: entry.NodeID,
+			Batadv: map[string]data.BatadvNeighbours{
+				mac: {Neighbours: links},
+			},
+		}
+	}
+}
+
+// nodeinfo returns the nodeinfo of a virtual node
+func (entry *OverlayNode) nodeinfo(nodeID string) *data.NodeInfo {
+	mac := entry.MAC
+	if mac == "" && len(nodeID) == 12 {
+		// derive a MAC address from the node ID
+		var parts []string
+		for i := 0; i < 12; i += 2 {
+			parts = append(parts, nodeID[i:i+2])
+		}
+		mac = strings.Join(parts, ":")
+	}
+
+	mesh := &data.BatInterface{}
+	mesh.Interfaces.Other = []string{mac}
+	return &data.NodeInfo{
+		NodeID: nodeID,
+		Network: data.Network{
+			Mac:  mac,
+			Mesh: map[string]*data.BatInterface{"bat0": mesh},
+		},
+		VPN: entry.Gateway,
+	}
+}
+
+// fill returns a copy of the nodeinfo with the missing values filled in
+func (entry *OverlayNode) fill(nodeinfo *data.NodeInfo) *data.NodeInfo {
+	c := *nodeinfo
+	if c.Hostname == "" {
+		c.Hostname = entry.Hostname
+	}
+	if c.Owner == nil && entry.Owner != "" {
+		c.Owner = &data.Owner{Contact: entry.Owner}
+	}
+	if c.System.SiteCode == "" {
+		c.System.SiteCode = entry.SiteCode
+	}
+	if c.System.DomainCode == "" {
+		c.System.DomainCode = entry.DomainCode
+	}
+	if c.Hardware.Model == "" {
+		c.Hardware.Model = entry.Model
+	}
+	if c.Location == nil && entry.Latitude != nil && entry.Longitude != nil {
+		c.Location = &data.Location{Latitude: *entry.Latitude, Longitude: *entry.Longitude}
+	}
+	return &c
+}
+
+// overlayFile reloads the overlay if the file was modified
+type overlayFile struct {
+	path    string
+	modTime time.Time
+	overlay *Overlay
+	failed  bool // the last attempt to load the overlay failed
+	sync.Mutex
+}
+
+// get returns the current overlay, which is nil if it could not be loaded yet
+func (file *overlayFile) get() *Overlay {
+	file.Lock()
+	defer file.Unlock()
+
+	info, err := os.Stat(file.path)
+	if err == nil && info.ModTime().Equal(file.modTime) {
+		return file.overlay
+	}
+
+	var overlay *Overlay
+	if err == nil {
+		file.modTime = info.ModTime()
+		overlay, err = ReadOverlayFile(file.path)
+	}
+	if err != nil {
+		if !file.failed {
+			log.Println("failed to load overlay, keeping the previous one:", err)
+			file.failed = true
+		}
+		return file.overlay
+	}
+	file.failed = false
+	log.Printf("loaded overlay with %d nodes", len(overlay.Nodes))
+	file.overlay = overlay
+	return overlay
+}
@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+func TestReadOverlayFile(t *testing.T) {
+	assert := assert.New(t)
+
+	overlay, err := ReadOverlayFile("testdata/overlay.toml")
+	assert.NoError(err)
+	assert.Len(overlay.Nodes, 4)
+	assert.Equal(8.81, *overlay.Nodes[0].Longitude)
+	assert.Equal("roof", overlay.Nodes[0].Tags["room"])
+	assert.Len(overlay.Nodes[3].Links, 3)
+
+	_, err = ReadOverlayFile("testdata/nodes.json")
+	assert.Error(err, "nodes have to be a list")
+
+	_, err = ReadOverlayFile("testdata/missing.toml")
+	assert.Error(err)
+}
+
+func TestOverlay(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &Config{}
+	config.Nodes.OverlayPath = "testdata/overlay.toml"
+	nodes := NewNodes(config)
+
+	nodes.Update("000000000001", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{
+			NodeID:  "000000000001",
+			Network: data.Network{Mac: "00:00:00:00:00:01"},
+		},
+	})
+	nodes.Update("000000000002", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{
+			NodeID:   "000000000002",
+			Hostname: "announced",
+			Network:  data.Network{Mac: "00:00:00:00:00:02"},
+		},
+	})
+
+	snapshot := nodes.Snapshot()
+	assert.Len(snapshot.List, 3)
+
+	node := snapshot.List["000000000001"]
+	assert.Equal("filled", node.Nodeinfo.Hostname)
+	assert.Equal("admin@example.org", node.Nodeinfo.Owner.Contact)
+	assert.Equal("ffhb", node.Nodeinfo.System.SiteCode)
+	assert.Equal(53.07, node.Nodeinfo.Location.Latitude)
+	assert.Equal("roof", node.Tags["room"])
+	assert.Equal("", nodes.List["000000000001"].Nodeinfo.Hostname, "collected data is not changed")
+
+	node = snapshot.List["000000000002"]
+	assert.Equal("announced", node.Nodeinfo.Hostname, "announced values are kept")
+	assert.Equal("by mac", node.Nodeinfo.Hardware.Model)
+
+	virtual := snapshot.List["0000000000ff"]
+	assert.True(virtual.Online)
+	assert.True(virtual.IsGateway())
+	assert.Equal("switch", virtual.Nodeinfo.Hostname)
+	assert.Equal("0000000000ff", snapshot.GetNodeIDbyMAC("00:00:00:00:00:ff"))
+
+	links := snapshot.NodeLinks(virtual)
+	assert.Len(links, 2)
+	for _, link := range links {
+		if link.TargetID == "000000000002" {
+			assert.Equal(128, link.TQ)
+		} else {
+			assert.Equal("000000000001", link.TargetID)
+			assert.Equal(255, link.TQ)
+		}
+	}
+
+	// cached until the overlay changes
+	assert.True(snapshot == nodes.Snapshot())
+}
+
+func TestOverlayReload(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-overlay")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "overlay.json")
+	file := &overlayFile{path: path}
+	assert.Nil(file.get())
+
+	assert.NoError(ioutil.WriteFile(path, []byte(`{"nodes":[{"node_id":"000000000001"}]}`), 0644))
+	overlay := file.get()
+	assert.Len(overlay.Nodes, 1)
+	assert.True(overlay == file.get(), "unchanged file is not reloaded")
+
+	// keep the previous overlay on errors
+	assert.NoError(ioutil.WriteFile(path, []byte(`{`), 0644))
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	assert.True(overlay == file.get())
+
+	assert.NoError(ioutil.WriteFile(path, []byte(`{"nodes":[]}`), 0644))
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second*2))
+	assert.Len(file.get().Nodes, 0)
+}
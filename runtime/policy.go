@@ -0,0 +1,85 @@
+package runtime
+
+import "time"
+
+// NodePolicy overrides settings for the matching nodes.
+// All given conditions have to match.
+type NodePolicy struct {
+	Sites   []string `toml:"sites"`    // site codes
+	Domains []string `toml:"domains"`  // domain codes
+	Models  []string `toml:"models"`   // hardware models
+	NodeIDs []string `toml:"node_ids"` // node IDs
+	Gateway *bool    `toml:"gateway"`  // whether the node is a gateway
+
+	OfflineAfter   Duration `toml:"offline_after"`   // Set node to offline if not seen within this period
+	PruneAfter     Duration `toml:"prune_after"`     // Remove node after this period of inactivity
+	UnicastRetries *int     `toml:"unicast_retries"` // Resend unicast requests to the node, which did not answer
+}
+
+// Match returns whether the policy applies to the node
+func (policy *NodePolicy) Match(nodeID string, node *Node) bool {
+	var site, domain, model string
+	if nodeinfo := node.Nodeinfo; nodeinfo != nil {
+		site = nodeinfo.System.SiteCode
+		domain = nodeinfo.System.DomainCode
+		model = nodeinfo.Hardware.Model
+	}
+
+	if len(policy.Sites) > 0 && !contains(policy.Sites, site) {
+		return false
+	}
+	if len(policy.Domains) > 0 && !contains(policy.Domains, domain) {
+		return false
+	}
+	if len(policy.Models) > 0 && !contains(policy.Models, model) {
+		return false
+	}
+	if len(policy.NodeIDs) > 0 && !contains(policy.NodeIDs, nodeID) {
+		return false
+	}
+	if policy.Gateway != nil && *policy.Gateway != node.IsGateway() {
+		return false
+	}
+	return true
+}
+
+// Policy returns the first policy matching the node or nil
+func (config *Config) Policy(nodeID string, node *Node) *NodePolicy {
+	if config == nil {
+		return nil
+	}
+	for i := range config.Nodes.Policies {
+		if policy := &config.Nodes.Policies[i]; policy.Match(nodeID, node) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// OfflineAfter returns the period after which the node is set to offline
+func (config *Config) OfflineAfter(nodeID string, node *Node) time.Duration {
+	if policy := config.Policy(nodeID, node); policy != nil && policy.OfflineAfter.Duration > 0 {
+		return policy.OfflineAfter.Duration
+	}
+	return config.Nodes.OfflineAfter.Duration
+}
+
+// PruneAfter returns the period of inactivity after which the node is removed
+func (config *Config) PruneAfter(nodeID string, node *Node) time.Duration {
+	if policy := config.Policy(nodeID, node); policy != nil && policy.PruneAfter.Duration > 0 {
+		return policy.PruneAfter.Duration
+	}
+	if prunePeriod := config.Nodes.PruneAfter.Duration; prunePeriod > 0 {
+		return prunePeriod
+	}
+	return time.Hour * 24 * 7 // our default
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+func TestPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	gateway := true
+	retries := 3
+	config := &Config{}
+	config.Nodes.OfflineAfter.Duration = time.Minute * 10
+	config.Nodes.Policies = []NodePolicy{
+		{
+			Gateway:        &gateway,
+			OfflineAfter:   Duration{Duration: time.Minute * 2},
+			UnicastRetries: &retries,
+		},
+		{
+			Sites:      []string{"ffhb"},
+			Models:     []string{"solar"},
+			PruneAfter: Duration{Duration: time.Hour * 24 * 365},
+		},
+		{
+			NodeIDs:      []string{"000000000001"},
+			OfflineAfter: Duration{Duration: time.Hour},
+		},
+		{
+			Domains:      []string{"ffhb_sued"},
+			OfflineAfter: Duration{Duration: time.Minute * 30},
+		},
+	}
+
+	node := &Node{Nodeinfo: &data.NodeInfo{}}
+	assert.Nil(config.Policy("000000000000", node))
+	assert.Nil((*Config)(nil).Policy("000000000000", node))
+	assert.Equal(time.Minute*10, config.OfflineAfter("000000000000", node))
+	assert.Equal(time.Hour*24*7, config.PruneAfter("000000000000", node))
+
+	node.Nodeinfo.VPN = true
+	assert.Equal(&config.Nodes.Policies[0], config.Policy("000000000000", node))
+	assert.Equal(time.Minute*2, config.OfflineAfter("000000000000", node))
+	assert.Equal(time.Hour*24*7, config.PruneAfter("000000000000", node))
+
+	node.Nodeinfo.VPN = false
+	node.Nodeinfo.System.SiteCode = "ffhb"
+	assert.Nil(config.Policy("000000000000", node), "all conditions have to match")
+	node.Nodeinfo.Hardware.Model = "solar"
+	assert.Equal(time.Hour*24*365, config.PruneAfter("000000000000", node))
+	assert.Equal(time.Minute*10, config.OfflineAfter("000000000000", node))
+
+	assert.Equal(time.Hour, config.OfflineAfter("000000000001", &Node{}))
+
+	node.Nodeinfo.Hardware.Model = ""
+	node.Nodeinfo.System.DomainCode = "ffhb_sued"
+	assert.Equal(time.Minute*30, config.OfflineAfter("000000000000", node))
+}
+
+func TestExpirePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	gateway := true
+	config := &Config{}
+	config.Nodes.OfflineAfter.Duration = time.Minute * 10
+	config.Nodes.Policies = []NodePolicy{
+		{Gateway: &gateway, OfflineAfter: Duration{Duration: time.Minute * 2}},
+		{NodeIDs: []string{"solar"}, PruneAfter: Duration{Duration: time.Hour * 24 * 365}},
+	}
+	nodes := NewNodes(config)
+
+	nodes.Update("gateway", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "gateway", VPN: true}})
+	nodes.Update("node", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "node"}})
+	nodes.Update("solar", &data.ResponseData{})
+	for _, node := range nodes.List {
+		node.Lastseen = node.Lastseen.Add(-time.Minute * 5)
+	}
+	nodes.List["solar"].Lastseen = nodes.List["solar"].Lastseen.Add(-time.Hour * 24 * 30)
+
+	nodes.expire()
+
+	assert.False(nodes.List["gateway"].Online)
+	assert.True(nodes.List["node"].Online)
+	assert.NotNil(nodes.List["solar"])
+	assert.False(nodes.List["solar"].Online)
+}
@@ -0,0 +1,258 @@
+package runtime
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// StateCompressionNone writes the state as plain JSON
+	StateCompressionNone = "none"
+	// StateCompressionGzip writes the state gzip compressed
+	StateCompressionGzip = "gzip"
+
+	defaultStateCompaction = time.Hour
+)
+
+// stateStore persists the nodes as a snapshot
+// and an append-only log of the changes since the snapshot
+type stateStore struct {
+	path       string
+	compress   bool
+	compaction time.Duration // maximum age of the snapshot
+
+	sequence     uint64    // sequence number of the last written changes
+	compacted    time.Time // time of the last snapshot
+	snapshotSize int64
+	logSize      int64
+}
+
+// stateSnapshot is the format of the state file, it is compatible to the plain nodes JSON
+type stateSnapshot struct {
+	Sequence uint64           `json:"sequence"`
+	List     map[string]*Node `json:"nodes"`
+}
+
+// stateChange is an entry of the change log
+type stateChange struct {
+	Sequence uint64 `json:"sequence"`
+	NodeID   string `json:"node_id"`
+	Node     *Node  `json:"node"` // nil if the node was removed
+}
+
+func (store *stateStore) logPath() string {
+	return store.path + ".log"
+}
+
+func (store *stateStore) backupPath() string {
+	return store.path + ".bak"
+}
+
+// load reads the snapshot, or the last good copy of it, and applies the change log
+func (store *stateStore) load() (map[string]*Node, error) {
+	snapshot, err := readStateSnapshot(store.path)
+	if err != nil {
+		var errBackup error
+		if snapshot, errBackup = readStateSnapshot(store.backupPath()); errBackup != nil {
+			return nil, err
+		}
+		log.Printf("failed to load state (%s), using last good copy %s", err, store.backupPath())
+	}
+	if snapshot.List == nil {
+		snapshot.List = make(map[string]*Node)
+	}
+	store.sequence = snapshot.Sequence
+
+	if err := store.replay(snapshot); err != nil {
+		log.Println("failed to replay state log:", err)
+	}
+	return snapshot.List, nil
+}
+
+// replay applies the changes, which are newer than the snapshot
+func (store *stateStore) replay(snapshot *stateSnapshot) error {
+	f, err := os.Open(store.logPath())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader, err := newStateReader(f)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(reader)
+	for {
+		var change stateChange
+		if err := decoder.Decode(&change); err == io.EOF {
+			return nil
+		} else if err != nil {
+			// keep the complete changes of an interrupted write
+			return err
+		}
+		if change.Sequence <= snapshot.Sequence {
+			continue
+		}
+		if change.Node != nil {
+			snapshot.List[change.NodeID] = change.Node
+		} else {
+			delete(snapshot.List, change.NodeID)
+		}
+		if change.Sequence > store.sequence {
+			store.sequence = change.Sequence
+		}
+	}
+}
+
+// needsCompaction returns whether a new snapshot should be written
+// instead of appending to the change log
+func (store *stateStore) needsCompaction() bool {
+	compaction := store.compaction
+	if compaction <= 0 {
+		compaction = defaultStateCompaction
+	}
+	return store.compacted.IsZero() ||
+		time.Since(store.compacted) >= compaction ||
+		store.logSize > store.snapshotSize
+}
+
+// compact writes a new snapshot, keeps the previous one as last good copy
+// and truncates the change log
+func (store *stateStore) compact(list map[string]*Node) error {
+	tmpFile := store.path + ".tmp"
+	f, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	err = store.write(f, func(encoder *json.Encoder) error {
+		return encoder.Encode(&stateSnapshot{Sequence: store.sequence, List: list})
+	})
+	if err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	size, err := fileSize(tmpFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(store.path); err == nil {
+		if err := os.Rename(store.path, store.backupPath()); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmpFile, store.path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(store.path))
+
+	// the changes are part of the snapshot now
+	if err := os.Remove(store.logPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	store.compacted = time.Now()
+	store.snapshotSize = size
+	store.logSize = 0
+	return nil
+}
+
+// append writes the changes to the change log
+func (store *stateStore) append(changes []stateChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	store.sequence++
+
+	f, err := os.OpenFile(store.logPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	err = store.write(f, func(encoder *json.Encoder) error {
+		for _, change := range changes {
+			change.Sequence = store.sequence
+			if err := encoder.Encode(&change); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	store.logSize, err = fileSize(store.logPath())
+	return err
+}
+
+// write encodes into the (compressed) file, syncs and closes it
+func (store *stateStore) write(f *os.File, encode func(*json.Encoder) error) error {
+	defer f.Close()
+
+	var writer io.Writer = f
+	var gzipWriter *gzip.Writer
+	if store.compress {
+		gzipWriter = gzip.NewWriter(f)
+		writer = gzipWriter
+	}
+	if err := encode(json.NewEncoder(writer)); err != nil {
+		return err
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+func readStateSnapshot(path string) (*stateSnapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader, err := newStateReader(f)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &stateSnapshot{}
+	if err := json.NewDecoder(reader).Decode(snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// newStateReader returns a reader of plain or gzip compressed state
+func newStateReader(r io.Reader) (io.Reader, error) {
+	reader := bufio.NewReader(r)
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(reader)
+	}
+	return reader, nil
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// syncDir persists renames within the directory
+func syncDir(path string) {
+	if dir, err := os.Open(path); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+}
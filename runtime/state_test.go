@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+func TestStateStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-state")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{}
+	config.Nodes.StatePath = filepath.Join(dir, "state.json")
+	config.Nodes.OfflineAfter.Duration = time.Minute
+
+	update := func(nodes *Nodes, nodeID, hostname string) {
+		nodes.Update(nodeID, &data.ResponseData{
+			NodeInfo: &data.NodeInfo{NodeID: nodeID, Hostname: hostname},
+		})
+	}
+
+	// first save writes a compressed snapshot
+	nodes := NewNodes(config)
+	update(nodes, "000000000001", "a")
+	update(nodes, "000000000002", "b")
+	nodes.save()
+
+	raw, err := ioutil.ReadFile(config.Nodes.StatePath)
+	assert.NoError(err)
+	assert.Equal([]byte{0x1f, 0x8b}, raw[:2])
+	_, err = os.Stat(config.Nodes.StatePath + ".log")
+	assert.True(os.IsNotExist(err))
+
+	// further saves only append the changes
+	update(nodes, "000000000001", "c")
+	nodes.save()
+	nodes.save() // nothing changed
+	delete(nodes.List, "000000000002")
+	nodes.Lock()
+	nodes.markChanged("000000000002")
+	nodes.Unlock()
+	update(nodes, "000000000003", "d")
+	nodes.save()
+
+	raw2, err := ioutil.ReadFile(config.Nodes.StatePath)
+	assert.NoError(err)
+	assert.Equal(raw, raw2, "snapshot should not be rewritten")
+	assert.Equal(uint64(2), nodes.state.sequence)
+
+	loaded := NewNodes(config)
+	assert.Len(loaded.List, 2)
+	assert.Equal("c", loaded.List["000000000001"].Nodeinfo.Hostname)
+	assert.Equal("d", loaded.List["000000000003"].Nodeinfo.Hostname)
+
+	// compaction keeps the previous snapshot and removes the log
+	nodes.state.compaction = time.Nanosecond
+	config.Nodes.StateCompaction.Duration = time.Nanosecond
+	nodes.save()
+	_, err = os.Stat(config.Nodes.StatePath + ".log")
+	assert.True(os.IsNotExist(err))
+	raw3, err := ioutil.ReadFile(config.Nodes.StatePath + ".bak")
+	assert.NoError(err)
+	assert.Equal(raw, raw3)
+
+	loaded = NewNodes(config)
+	assert.Len(loaded.List, 2)
+	assert.Equal(uint64(2), loaded.state.sequence)
+
+	// log entries of the snapshot are ignored after an interrupted compaction
+	config.Nodes.StateCompaction.Duration = 0
+	update(nodes, "000000000001", "e")
+	nodes.save()
+	changes, err := ioutil.ReadFile(config.Nodes.StatePath + ".log")
+	assert.NoError(err)
+
+	update(nodes, "000000000001", "f")
+	nodes.state.compacted = time.Time{}
+	nodes.save()
+	assert.NoError(ioutil.WriteFile(config.Nodes.StatePath+".log", changes, 0644))
+
+	loaded = NewNodes(config)
+	assert.Equal("f", loaded.List["000000000001"].Nodeinfo.Hostname)
+
+	// fall back to the last good copy and apply the log
+	assert.NoError(ioutil.WriteFile(config.Nodes.StatePath, []byte("{"), 0644))
+	loaded = NewNodes(config)
+	assert.Len(loaded.List, 2)
+	assert.Equal("e", loaded.List["000000000001"].Nodeinfo.Hostname)
+}
+
+func TestStateStoreUncompressed(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-state")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{}
+	config.Nodes.StatePath = filepath.Join(dir, "state.json")
+	config.Nodes.StateCompression = StateCompressionNone
+
+	nodes := NewNodes(config)
+	nodes.Update("000000000001", &data.ResponseData{})
+	nodes.save()
+	nodes.Update("000000000002", &data.ResponseData{})
+	nodes.save()
+
+	raw, err := ioutil.ReadFile(config.Nodes.StatePath)
+	assert.NoError(err)
+	assert.Equal(byte('{'), raw[0])
+
+	raw, err = ioutil.ReadFile(config.Nodes.StatePath + ".log")
+	assert.NoError(err)
+	assert.Contains(string(raw), `"node_id":"000000000002"`)
+
+	assert.Len(NewNodes(config).List, 2)
+}
@@ -1,10 +1,21 @@
 package runtime
 
+import (
+	"strconv"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
 const (
 	DISABLED_AUTOUPDATER = "disabled"
 	GLOBAL_SITE = "global"
+	GLOBAL_DOMAIN = "global"
 )
 
+// NewNodesPeriod is the period in which a node is counted as new after it was seen first
+const NewNodesPeriod = 24 * time.Hour
+
 // CounterMap to manage multiple values
 type CounterMap map[string]uint32
 
@@ -15,40 +26,86 @@ type GlobalStats struct {
 	ClientsWifi24 uint32
 	ClientsWifi5  uint32
 	Gateways      uint32
-	Nodes         uint32
+	Nodes         uint32 // online nodes
+
+	Known                uint32 // online and offline nodes
+	Offline              uint32
+	New                  uint32 // nodes seen first within the NewNodesPeriod
+	NodesWithLocation    uint32
+	NodesWithoutLocation uint32
+	NodesVPN             uint32 // online nodes (no gateways) with an established mesh VPN
+	NodesMeshOnly        uint32 // online nodes (no gateways) without an established mesh VPN
 
-	Firmwares   CounterMap
-	Models      CounterMap
-	Autoupdater CounterMap
+	Traffic TrafficSum             // summed rates of the online nodes
+	Airtime map[string]*AirtimeSum // summed utilization of the radios by frequency name (11g, 11a)
+
+	Firmwares     CounterMap
+	FirmwareBases CounterMap
+	Models        CounterMap
+	Autoupdater   CounterMap
+	BatmanAdv     CounterMap // batman-adv versions
+	Nproc         CounterMap // number of processors
+	Memory        CounterMap // memory classes, e.g. 64MB
 }
 
-//NewGlobalStats returns global statistics for InfluxDB
-func NewGlobalStats(nodes *Nodes, sites []string) (result map[string]*GlobalStats) {
-	result = make(map[string]*GlobalStats)
+// TrafficSum are summed rates in bytes per second
+type TrafficSum struct {
+	Rx      float64
+	Tx      float64
+	Forward float64
+	MgmtRx  float64
+	MgmtTx  float64
+}
 
-	result[GLOBAL_SITE] = &GlobalStats{
-		Firmwares:   make(CounterMap),
-		Models:      make(CounterMap),
-		Autoupdater: make(CounterMap),
-	}
+// AirtimeSum are the summed utilizations of the radios of a frequency band,
+// divide them by the number of radios for the average
+type AirtimeSum struct {
+	Radios   uint32
+	ChanUtil float64
+	RxUtil   float64
+	TxUtil   float64
+}
 
+//NewGlobalStats returns global statistics for InfluxDB
+// per site and domain, indexed by GLOBAL_SITE and GLOBAL_DOMAIN for all nodes
+func NewGlobalStats(nodes *Nodes, sites, domains []string) (result map[string]map[string]*GlobalStats) {
+	result = make(map[string]map[string]*GlobalStats)
+
+	sites = append([]string{GLOBAL_SITE}, sites...)
+	domains = append([]string{GLOBAL_DOMAIN}, domains...)
 	for _, site := range sites {
-		result[site] = &GlobalStats{
-			Firmwares:   make(CounterMap),
-			Models:      make(CounterMap),
-			Autoupdater: make(CounterMap),
+		result[site] = make(map[string]*GlobalStats)
+		for _, domain := range domains {
+			result[site][domain] = newGlobalStats()
 		}
 	}
 
+	newSince := time.Now().Add(-NewNodesPeriod)
+
 	nodes.RLock()
 	for _, node := range nodes.List {
-		if node.Online {
-			result[GLOBAL_SITE].Add(node)
-
-			if info := node.Nodeinfo; info != nil {
-				site := info.System.SiteCode
-				if _, exist := result[site]; exist {
-					result[site].Add(node)
+		nodeSites := []string{GLOBAL_SITE}
+		nodeDomains := []string{GLOBAL_DOMAIN}
+		if info := node.Nodeinfo; info != nil {
+			if site := info.System.SiteCode; site != GLOBAL_SITE {
+				nodeSites = append(nodeSites, site)
+			}
+			if domain := info.System.DomainCode; domain != GLOBAL_DOMAIN {
+				nodeDomains = append(nodeDomains, domain)
+			}
+		}
+		for _, site := range nodeSites {
+			for _, domain := range nodeDomains {
+				if stats := result[site][domain]; stats != nil {
+					stats.Known++
+					if node.Firstseen.GetTime().After(newSince) {
+						stats.New++
+					}
+					if node.Online {
+						stats.Add(node)
+					} else {
+						stats.Offline++
+					}
 				}
 			}
 		}
@@ -57,6 +114,19 @@ func NewGlobalStats(nodes *Nodes, sites []string) (result map[string]*GlobalStat
 	return
 }
 
+func newGlobalStats() *GlobalStats {
+	return &GlobalStats{
+		Airtime:       make(map[string]*AirtimeSum),
+		Firmwares:     make(CounterMap),
+		FirmwareBases: make(CounterMap),
+		Models:        make(CounterMap),
+		Autoupdater:   make(CounterMap),
+		BatmanAdv:     make(CounterMap),
+		Nproc:         make(CounterMap),
+		Memory:        make(CounterMap),
+	}
+}
+
 // Add values to GlobalStats
 // if node is online
 func (s *GlobalStats) Add(node *Node) {
@@ -66,19 +136,85 @@ func (s *GlobalStats) Add(node *Node) {
 		s.ClientsWifi24 += stats.Clients.Wifi24
 		s.ClientsWifi5 += stats.Clients.Wifi5
 		s.ClientsWifi += stats.Clients.Wifi
+		s.Memory.Increment(memoryClass(stats.Memory.Total))
+
+		if rates := stats.Rates; rates != nil {
+			s.Traffic.add(rates)
+		}
+		for _, airtime := range stats.Wireless {
+			sum := s.Airtime[airtime.FrequencyName()]
+			if sum == nil {
+				sum = &AirtimeSum{}
+				s.Airtime[airtime.FrequencyName()] = sum
+			}
+			sum.Radios++
+			sum.ChanUtil += float64(airtime.ChanUtil)
+			sum.RxUtil += float64(airtime.RxUtil)
+			sum.TxUtil += float64(airtime.TxUtil)
+		}
 	}
 	if node.IsGateway() {
 		s.Gateways++
+	} else if len(node.MeshVPNPeers()) > 0 {
+		s.NodesVPN++
+	} else {
+		s.NodesMeshOnly++
 	}
 	if info := node.Nodeinfo; info != nil {
 		s.Models.Increment(info.Hardware.Model)
 		s.Firmwares.Increment(info.Software.Firmware.Release)
+		s.FirmwareBases.Increment(info.Software.Firmware.Base)
+		s.BatmanAdv.Increment(info.Software.BatmanAdv.Version)
+		if info.Hardware.Nproc > 0 {
+			s.Nproc.Increment(strconv.Itoa(info.Hardware.Nproc))
+		}
 		if info.Software.Autoupdater.Enabled {
 			s.Autoupdater.Increment(info.Software.Autoupdater.Branch)
 		} else {
 			s.Autoupdater.Increment(DISABLED_AUTOUPDATER)
 		}
+		if info.Location != nil {
+			s.NodesWithLocation++
+		} else {
+			s.NodesWithoutLocation++
+		}
+	} else {
+		s.NodesWithoutLocation++
+	}
+}
+
+func (sum *TrafficSum) add(rates *data.Rates) {
+	if rate := rates.Rx; rate != nil {
+		sum.Rx += rate.Bytes
+	}
+	if rate := rates.Tx; rate != nil {
+		sum.Tx += rate.Bytes
+	}
+	if rate := rates.Forward; rate != nil {
+		sum.Forward += rate.Bytes
+	}
+	if rate := rates.MgmtRx; rate != nil {
+		sum.MgmtRx += rate.Bytes
+	}
+	if rate := rates.MgmtTx; rate != nil {
+		sum.MgmtTx += rate.Bytes
+	}
+}
+
+// memoryClass returns the memory size rounded up to a power of two, e.g. 64MB for 59000 kB
+func memoryClass(totalKB uint32) string {
+	if totalKB == 0 {
+		return ""
+	}
+	// uint64 to not overflow for values above 2^31 kB
+	mb := uint64(1)
+	for mb*1024 < uint64(totalKB) {
+		mb *= 2
+	}
+	if mb >= 1024 {
+		return strconv.Itoa(int(mb/1024)) + "GB"
 	}
+	return strconv.Itoa(int(mb)) + "MB"
 }
 
 // Increment counter in the map by one
@@ -1,56 +1,129 @@
 package runtime
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/jsontime"
 )
 
-const TEST_SITE = "ffxx"
+const (
+	TEST_SITE   = "ffxx"
+	TEST_DOMAIN = "ffxx_nord"
+)
 
 func TestGlobalStats(t *testing.T) {
-	stats := NewGlobalStats(createTestNodes(), []string{TEST_SITE})
+	stats := NewGlobalStats(createTestNodes(), []string{TEST_SITE}, []string{TEST_DOMAIN})
 
 	assert := assert.New(t)
 	assert.Len(stats, 2)
+	assert.Len(stats[GLOBAL_SITE], 2)
 
 	//check GLOBAL_SITE stats
-	assert.EqualValues(1, stats[GLOBAL_SITE].Gateways)
-	assert.EqualValues(3, stats[GLOBAL_SITE].Nodes)
-	assert.EqualValues(25, stats[GLOBAL_SITE].Clients)
+	assert.EqualValues(1, stats[GLOBAL_SITE][GLOBAL_DOMAIN].Gateways)
+	assert.EqualValues(3, stats[GLOBAL_SITE][GLOBAL_DOMAIN].Nodes)
+	assert.EqualValues(25, stats[GLOBAL_SITE][GLOBAL_DOMAIN].Clients)
 
 	// check models
-	assert.Len(stats[GLOBAL_SITE].Models, 2)
-	assert.EqualValues(2, stats[GLOBAL_SITE].Models["TP-Link 841"])
-	assert.EqualValues(1, stats[GLOBAL_SITE].Models["Xeon Multi-Core"])
+	assert.Len(stats[GLOBAL_SITE][GLOBAL_DOMAIN].Models, 2)
+	assert.EqualValues(2, stats[GLOBAL_SITE][GLOBAL_DOMAIN].Models["TP-Link 841"])
+	assert.EqualValues(1, stats[GLOBAL_SITE][GLOBAL_DOMAIN].Models["Xeon Multi-Core"])
 
 	// check firmwares
-	assert.Len(stats[GLOBAL_SITE].Firmwares, 1)
-	assert.EqualValues(1, stats[GLOBAL_SITE].Firmwares["2016.1.6+entenhausen1"])
+	assert.Len(stats[GLOBAL_SITE][GLOBAL_DOMAIN].Firmwares, 1)
+	assert.EqualValues(1, stats[GLOBAL_SITE][GLOBAL_DOMAIN].Firmwares["2016.1.6+entenhausen1"])
 
 	// check autoupdater
-	assert.Len(stats[GLOBAL_SITE].Autoupdater, 2)
-	assert.EqualValues(1, stats[GLOBAL_SITE].Autoupdater["stable"])
+	assert.Len(stats[GLOBAL_SITE][GLOBAL_DOMAIN].Autoupdater, 2)
+	assert.EqualValues(1, stats[GLOBAL_SITE][GLOBAL_DOMAIN].Autoupdater["stable"])
 
 	// check TEST_SITE stats
-	assert.EqualValues(1, stats[TEST_SITE].Gateways)
-	assert.EqualValues(2, stats[TEST_SITE].Nodes)
-	assert.EqualValues(23, stats[TEST_SITE].Clients)
+	assert.EqualValues(1, stats[TEST_SITE][GLOBAL_DOMAIN].Gateways)
+	assert.EqualValues(2, stats[TEST_SITE][GLOBAL_DOMAIN].Nodes)
+	assert.EqualValues(23, stats[TEST_SITE][GLOBAL_DOMAIN].Clients)
 
 	// check models
-	assert.Len(stats[TEST_SITE].Models, 2)
-	assert.EqualValues(1, stats[TEST_SITE].Models["TP-Link 841"])
-	assert.EqualValues(1, stats[TEST_SITE].Models["Xeon Multi-Core"])
+	assert.Len(stats[TEST_SITE][GLOBAL_DOMAIN].Models, 2)
+	assert.EqualValues(1, stats[TEST_SITE][GLOBAL_DOMAIN].Models["TP-Link 841"])
+	assert.EqualValues(1, stats[TEST_SITE][GLOBAL_DOMAIN].Models["Xeon Multi-Core"])
 
 	// check firmwares
-	assert.Len(stats[TEST_SITE].Firmwares, 1)
-	assert.EqualValues(1, stats[TEST_SITE].Firmwares["2016.1.6+entenhausen1"])
+	assert.Len(stats[TEST_SITE][GLOBAL_DOMAIN].Firmwares, 1)
+	assert.EqualValues(1, stats[TEST_SITE][GLOBAL_DOMAIN].Firmwares["2016.1.6+entenhausen1"])
 
 	// check autoupdater
-	assert.Len(stats[TEST_SITE].Autoupdater, 1)
-	assert.EqualValues(0, stats[TEST_SITE].Autoupdater["stable"])
+	assert.Len(stats[TEST_SITE][GLOBAL_DOMAIN].Autoupdater, 1)
+	assert.EqualValues(0, stats[TEST_SITE][GLOBAL_DOMAIN].Autoupdater["stable"])
+
+	// check TEST_DOMAIN stats
+	assert.EqualValues(1, stats[GLOBAL_SITE][TEST_DOMAIN].Nodes)
+	assert.EqualValues(23, stats[GLOBAL_SITE][TEST_DOMAIN].Clients)
+	assert.EqualValues(1, stats[TEST_SITE][TEST_DOMAIN].Nodes)
+}
+
+func TestGlobalStatsCounters(t *testing.T) {
+	assert := assert.New(t)
+	nodes := createTestNodes()
+
+	node := nodes.List["abcdef012345"]
+	node.Firstseen = jsontime.Now()
+	node.Nodeinfo.Location = &data.Location{Latitude: 53.1, Longitude: 8.8}
+	node.Nodeinfo.Hardware.Nproc = 1
+	node.Nodeinfo.Software.Firmware.Base = "gluon-v2016.1.6"
+	node.Nodeinfo.Software.BatmanAdv.Version = "2016.5"
+	node.Statistics.Memory.Total = 28000
+	node.Statistics.Rates = &data.Rates{
+		Rx: &data.TrafficRate{Bytes: 100},
+		Tx: &data.TrafficRate{Bytes: 50},
+	}
+	node.Statistics.Wireless = data.WirelessStatistics{
+		{Frequency: 2412, ChanUtil: 20},
+		{Frequency: 5180, ChanUtil: 5},
+	}
+	node.Statistics.MeshVPN = &data.MeshVPN{Peers: map[string]*data.MeshVPNPeerLink{
+		"vpn01": {Established: 100},
+	}}
+	nodes.AddNode(&Node{
+		Firstseen: jsontime.Now().Add(-NewNodesPeriod * 2),
+		Nodeinfo:  &data.NodeInfo{NodeID: "offline"},
+	})
+
+	stats := NewGlobalStats(nodes, nil, nil)[GLOBAL_SITE][GLOBAL_DOMAIN]
+	assert.EqualValues(4, stats.Known)
+	assert.EqualValues(3, stats.Nodes)
+	assert.EqualValues(1, stats.Offline)
+	assert.EqualValues(1, stats.New)
+	assert.EqualValues(1, stats.NodesWithLocation)
+	assert.EqualValues(2, stats.NodesWithoutLocation)
+	assert.EqualValues(1, stats.NodesVPN)
+	assert.EqualValues(1, stats.NodesMeshOnly)
+	assert.EqualValues(1, stats.Gateways)
+
+	assert.Equal(CounterMap{"gluon-v2016.1.6": 1}, stats.FirmwareBases)
+	assert.Equal(CounterMap{"2016.5": 1}, stats.BatmanAdv)
+	assert.Equal(CounterMap{"1": 1}, stats.Nproc)
+	assert.Equal(CounterMap{"32MB": 1}, stats.Memory)
+
+	assert.Equal(TrafficSum{Rx: 100, Tx: 50}, stats.Traffic)
+	assert.Len(stats.Airtime, 2)
+	assert.Equal(&AirtimeSum{Radios: 1, ChanUtil: 20}, stats.Airtime["11g"])
+}
+
+func TestMemoryClass(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("", memoryClass(0))
+	assert.Equal("32MB", memoryClass(27000))
+	assert.Equal("64MB", memoryClass(59000))
+	assert.Equal("64MB", memoryClass(65536))
+	assert.Equal("128MB", memoryClass(125000))
+	assert.Equal("1GB", memoryClass(1000000))
+	assert.Equal("2GB", memoryClass(1900000))
+	assert.Equal("4096GB", memoryClass(3000000000))
+	assert.Equal("4096GB", memoryClass(math.MaxUint32))
 }
 
 func createTestNodes() *Nodes {
@@ -69,7 +142,8 @@ func createTestNodes() *Nodes {
 				Model: "TP-Link 841",
 			},
 			System: data.System{
-				SiteCode: TEST_SITE,
+				SiteCode:   TEST_SITE,
+				DomainCode: TEST_DOMAIN,
 			},
 		},
 	}
@@ -0,0 +1,160 @@
+package webserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// AdminPath is the prefix of the node management API
+const AdminPath = "/api/admin/nodes/"
+
+// ReloadPath is the endpoint to reload the configuration
+const ReloadPath = "/api/admin/reload"
+
+// ErrCollectorStopped is returned by the query function if the collector is not running
+var ErrCollectorStopped = errors.New("collector is not running")
+
+// adminHandler serves the node management API:
+//
+//	GET    /api/admin/nodes/<id>                  the stored node
+//	DELETE /api/admin/nodes/<id>                  remove the node
+//	POST   /api/admin/nodes/<id>/merge?into=<id>  merge the node into another one
+//	PATCH  /api/admin/nodes/<id>/overrides        set overrides (JSON object)
+//	DELETE /api/admin/nodes/<id>/overrides        remove all overrides
+//	PUT    /api/admin/nodes/<id>/maintenance      set the maintenance flag
+//	DELETE /api/admin/nodes/<id>/maintenance      remove the maintenance flag
+//	POST   /api/admin/nodes/<id>/query            send a unicast request
+type adminHandler struct {
+	token string
+	nodes *runtime.Nodes
+	query func(nodeID string) error
+}
+
+// NewAdminHandler returns the node management API, which requires the token as bearer.
+// query sends a request to a node, it may be nil if the collector is not running.
+func NewAdminHandler(token string, nodes *runtime.Nodes, query func(nodeID string) error) http.Handler {
+	return &adminHandler{
+		token: token,
+		nodes: nodes,
+		query: query,
+	}
+}
+
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorized(h.token, w, r) {
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, AdminPath), "/")
+	nodeID := parts[0]
+	var action string
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	if nodeID == "" || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	var err error
+	switch action + " " + r.Method {
+	case " GET":
+	case " DELETE":
+		if err = h.nodes.Delete(nodeID); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	case "merge POST":
+		into := r.URL.Query().Get("into")
+		if into == "" || into == nodeID {
+			http.Error(w, "invalid node ID to merge into", http.StatusBadRequest)
+			return
+		}
+		if err = h.nodes.Merge(nodeID, into); err == nil {
+			nodeID = into
+		}
+	case "overrides PATCH":
+		body, _ := ioutil.ReadAll(r.Body)
+		if err = json.Unmarshal(body, &runtime.NodeOverrides{}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = h.nodes.UpdateOverrides(nodeID, func(overrides *runtime.NodeOverrides) error {
+			return json.Unmarshal(body, overrides)
+		})
+	case "overrides DELETE":
+		err = h.nodes.UpdateOverrides(nodeID, func(overrides *runtime.NodeOverrides) error {
+			*overrides = runtime.NodeOverrides{}
+			return nil
+		})
+	case "maintenance PUT":
+		err = h.nodes.SetMaintenance(nodeID, true)
+	case "maintenance DELETE":
+		err = h.nodes.SetMaintenance(nodeID, false)
+	case "query POST":
+		if h.query == nil {
+			err = ErrCollectorStopped
+		} else if err = h.query(nodeID); err == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if err == ErrCollectorStopped {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err == runtime.ErrNodeNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	node := h.nodes.Get(nodeID)
+	if node == nil {
+		http.Error(w, runtime.ErrNodeNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(node)
+}
+
+// NewReloadHandler returns the endpoint to reload the configuration (POST), which requires the token as bearer
+func NewReloadHandler(token string, reload func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(token, w, r) {
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := reload(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// authorized checks the bearer token and responds with an error if it does not match
+func authorized(token string, w http.ResponseWriter, r *http.Request) bool {
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
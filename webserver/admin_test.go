@@ -0,0 +1,113 @@
+package webserver
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestAdminHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := runtime.NewNodes(&runtime.Config{})
+	nodes.Update("000000000001", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "000000000001"}})
+	nodes.Update("000000000002", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "000000000002"}})
+
+	var queried string
+	handler := NewAdminHandler("secret", nodes, func(nodeID string) error {
+		if nodes.Get(nodeID) == nil {
+			return runtime.ErrNodeNotFound
+		}
+		queried = nodeID
+		return nil
+	})
+
+	request := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, AdminPath+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(http.StatusUnauthorized, request("GET", "000000000001", "", "wrong").Code)
+	assert.Equal(http.StatusNotFound, request("GET", "unknown", "", "secret").Code)
+	assert.Equal(http.StatusNotFound, request("GET", "", "", "secret").Code)
+	assert.Equal(http.StatusMethodNotAllowed, request("POST", "000000000001", "", "secret").Code)
+
+	w := request("GET", "000000000001", "", "secret")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), `"node_id":"000000000001"`)
+
+	// overrides
+	assert.Equal(http.StatusBadRequest, request("PATCH", "000000000001/overrides", "{", "secret").Code)
+	w = request("PATCH", "000000000001/overrides", `{"hostname":"pinned"}`, "secret")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), `"overrides":{"hostname":"pinned"}`)
+	assert.Equal("pinned", nodes.Snapshot().List["000000000001"].Nodeinfo.Hostname)
+	assert.Equal(http.StatusOK, request("DELETE", "000000000001/overrides", "", "secret").Code)
+	assert.Nil(nodes.Get("000000000001").Overrides)
+
+	// maintenance
+	assert.Equal(http.StatusOK, request("PUT", "000000000001/maintenance", "", "secret").Code)
+	assert.True(nodes.Get("000000000001").Maintenance)
+	assert.Equal(http.StatusOK, request("DELETE", "000000000001/maintenance", "", "secret").Code)
+	assert.False(nodes.Get("000000000001").Maintenance)
+
+	// query
+	assert.Equal(http.StatusAccepted, request("POST", "000000000001/query", "", "secret").Code)
+	assert.Equal("000000000001", queried)
+	assert.Equal(http.StatusNotFound, request("POST", "unknown/query", "", "secret").Code)
+
+	// merge and delete
+	assert.Equal(http.StatusBadRequest, request("POST", "000000000001/merge", "", "secret").Code)
+	assert.Equal(http.StatusOK, request("POST", "000000000001/merge?into=000000000002", "", "secret").Code)
+	assert.Nil(nodes.Get("000000000001"))
+	assert.Equal(http.StatusNoContent, request("DELETE", "000000000002", "", "secret").Code)
+	assert.Len(nodes.List, 0)
+
+	// without collector
+	handler = NewAdminHandler("secret", nodes, nil)
+	assert.Equal(http.StatusServiceUnavailable, request("POST", "000000000001/query", "", "secret").Code)
+
+	// disabled without token
+	handler = NewAdminHandler("", nodes, nil)
+	assert.Equal(http.StatusUnauthorized, request("GET", "000000000001", "", "").Code)
+}
+
+func TestReloadHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	var err error
+	reloads := 0
+	handler := NewReloadHandler("secret", func() error {
+		reloads++
+		return err
+	})
+	request := func(method, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, ReloadPath, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(http.StatusUnauthorized, request("POST", "wrong").Code)
+	assert.Equal(http.StatusMethodNotAllowed, request("GET", "secret").Code)
+	assert.Equal(0, reloads)
+
+	assert.Equal(http.StatusNoContent, request("POST", "secret").Code)
+	assert.Equal(1, reloads)
+
+	err = errors.New("invalid config")
+	w := request("POST", "secret")
+	assert.Equal(http.StatusInternalServerError, w.Code)
+	assert.Contains(w.Body.String(), "invalid config")
+}
@@ -1,20 +1,50 @@
 package webserver
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/NYTimes/gziphandler"
 )
 
+// Server is a webserver, which statically serves a directory
+// and allows to add further handlers (e.g. for an API)
+type Server struct {
+	*http.Server
+	mux *http.ServeMux
+}
+
 // New creates a new webserver and starts it
-func New(bindAddr, webroot string) *http.Server {
-	return &http.Server{
-		Addr:    bindAddr,
-		Handler: gziphandler.GzipHandler(http.FileServer(http.Dir(webroot))),
+func New(bindAddr, webroot string) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir(webroot)))
+
+	return &Server{
+		Server: &http.Server{
+			Addr:    bindAddr,
+			Handler: gziphandler.GzipHandler(mux),
+		},
+		mux: mux,
 	}
 }
 
-func Start(srv *http.Server) {
+// Handle registers the handler for the given pattern
+func (srv *Server) Handle(pattern string, handler http.Handler) {
+	srv.mux.Handle(pattern, handler)
+}
+
+// HandleJSON registers a handler for the given pattern,
+// which responds with the JSON encoded result of f
+func (srv *Server) HandleJSON(pattern string, f func() interface{}) {
+	srv.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(f()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+}
+
+func Start(srv *Server) {
 	// service connections
 	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 		panic(err)
@@ -1,6 +1,8 @@
 package webserver
 
 import (
+	"io/ioutil"
+	"net/http"
 	"testing"
 	"time"
 
@@ -13,10 +15,21 @@ func TestWebserver(t *testing.T) {
 	srv := New(":8080", "/tmp")
 	assert.NotNil(srv)
 
+	srv.HandleJSON("/api/test", func() interface{} {
+		return map[string]int{"count": 42}
+	})
+
 	go Start(srv)
 
 	time.Sleep(time.Millisecond * 200)
 
+	res, err := http.Get("http://127.0.0.1:8080/api/test")
+	assert.NoError(err)
+	assert.Equal("application/json", res.Header.Get("Content-Type"))
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal("{\"count\":42}\n", string(body))
+
 	assert.Panics(func() {
 		Start(srv)
 	}, "not allowed to listen twice")